@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os/signal"
@@ -12,8 +13,12 @@ import (
 	"git-gud-bot/internal/api/handler"
 	"git-gud-bot/internal/api/middleware"
 	"git-gud-bot/internal/config"
+	"git-gud-bot/internal/repository"
+	"git-gud-bot/internal/repository/memory"
 	"git-gud-bot/internal/repository/postgres"
+	"git-gud-bot/internal/repository/sqlite"
 	"git-gud-bot/internal/service"
+	"git-gud-bot/internal/worker"
 	"git-gud-bot/pkg/analyzer"
 	"git-gud-bot/pkg/github"
 
@@ -23,24 +28,52 @@ import (
 func main() {
 	// Initialize configuration
 	cfg := config.New()
+	if err := cfg.Worker.Validate(); err != nil {
+		log.Fatalf("Invalid worker configuration: %v", err)
+	}
+
+	// Server run context
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to the database, waiting for it to come up
+	store, err := openStores(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
 
 	// Initialize router
 	engine := gin.Default()
 
 	// Initialize dependencies
-	githubClient := github.NewClient(cfg.GithubToken)
+	githubHosts, githubClient, err := newGithubHosts(cfg.GithubHosts)
+	if err != nil {
+		log.Fatalf("Failed to configure GitHub clients: %v", err)
+	}
 	codeAnalyzer := analyzer.NewCodeAnalyzer(githubClient)
 
 	// Initialize services and repositories
-	reviewRepo := postgres.NewReviewRepository(cfg.DB)
-	reviewService := service.NewReviewService(reviewRepo, githubClient, codeAnalyzer)
+	reviewService := service.NewReviewService(store.reviews, githubHosts, codeAnalyzer)
 	reviewHandler := handler.NewReviewHandler(reviewService)
+	webhookService := service.NewWebhookService(reviewService, store.deliveries)
+	webhookHandler := handler.NewWebhookHandler(webhookService, cfg.WebhookSecret)
+	deliveryHandler := handler.NewDeliveryHandler(webhookService)
+
+	// Initialize background workers
+	workerPool := worker.NewPool(store.jobs, reviewService, worker.Config{
+		Workers:      cfg.Worker.Count,
+		PollInterval: cfg.Worker.PollInterval,
+		MaxAttempts:  cfg.Worker.MaxAttempts,
+		BaseBackoff:  cfg.Worker.BaseBackoff,
+		MaxBackoff:   cfg.Worker.MaxBackoff,
+		Lease:        cfg.Worker.Lease,
+	})
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware()
 
 	// Setup router with all dependencies
-	router := api.NewRouter(reviewHandler, authMiddleware)
+	router := api.NewRouter(reviewHandler, webhookHandler, deliveryHandler, authMiddleware)
 	router.Setup(engine)
 
 	// Create HTTP server
@@ -49,9 +82,10 @@ func main() {
 		Handler: engine,
 	}
 
-	// Server run context
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+	// Start workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	workerPool.Start(workerCtx)
 
 	// Start server
 	go func() {
@@ -65,12 +99,124 @@ func main() {
 	stop()
 	log.Println("Shutting down gracefully, press Ctrl+C again to force")
 
+	// Stop claiming jobs; the ones already running carry on
+	stopWorkers()
+
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown: ", err)
+		log.Println("Server forced to shutdown: ", err)
+	}
+
+	// Let in-flight jobs finish and record their outcome before the database
+	// goes away; those that take too long are cancelled and requeued
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Worker.DrainTimeout)
+	defer cancelDrain()
+	if err := workerPool.Drain(drainCtx); err != nil {
+		log.Println("Requeued review jobs still running at shutdown: ", err)
+	}
+
+	if err := store.close(); err != nil {
+		log.Println("Failed to close database: ", err)
 	}
 
 	log.Println("Server exiting")
 }
+
+// stores holds the repositories of the configured driver
+type stores struct {
+	reviews    repository.ReviewStore
+	jobs       repository.JobStore
+	deliveries repository.DeliveryStore
+	close      func() error
+}
+
+// openStores connects to the database selected by DB_DRIVER. Postgres is
+// expected to be migrated already; SQLite creates its schema on open.
+func openStores(ctx context.Context, cfg config.DatabaseConfig) (*stores, error) {
+	switch cfg.Driver {
+	case config.DriverPostgres:
+		db, err := postgres.Open(ctx, postgres.Config{
+			DSN:             cfg.DSN(),
+			MaxOpenConns:    cfg.MaxOpenConns,
+			MaxIdleConns:    cfg.MaxIdleConns,
+			ConnMaxLifetime: cfg.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+			ConnectAttempts: cfg.ConnectAttempts,
+			ConnectBackoff:  cfg.ConnectBackoff,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &stores{
+			reviews:    postgres.NewReviewRepository(db),
+			jobs:       postgres.NewJobRepository(db),
+			deliveries: postgres.NewDeliveryRepository(db),
+			close:      db.Close,
+		}, nil
+	case config.DriverSQLite:
+		db, err := sqlite.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return &stores{
+			reviews:    sqlite.NewReviewRepository(db),
+			jobs:       sqlite.NewJobRepository(db),
+			deliveries: sqlite.NewDeliveryRepository(db),
+			close:      db.Close,
+		}, nil
+	case config.DriverMemory:
+		store := memory.New()
+		return &stores{
+			reviews:    store,
+			jobs:       store,
+			deliveries: store,
+			close:      func() error { return nil },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q", cfg.Driver)
+	}
+}
+
+// newGithubHosts builds a client per configured GitHub host and returns them
+// along with the github.com client
+func newGithubHosts(configs []config.GithubHostConfig) (*github.Hosts, *github.Client, error) {
+	hosts := github.NewHosts()
+
+	var dotCom *github.Client
+	for _, cfg := range configs {
+		client, err := newGithubClient(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", cfg.Name, err)
+		}
+		hosts.Add(cfg.Name, client)
+		if dotCom == nil {
+			dotCom = client
+		}
+	}
+
+	return hosts, dotCom, nil
+}
+
+// newGithubClient authenticates as a GitHub App when one is configured and
+// falls back to the token otherwise
+func newGithubClient(cfg config.GithubHostConfig) (*github.Client, error) {
+	endpoints := github.DotComEndpoints()
+	if cfg.WebURL != "" {
+		var err error
+		if endpoints, err = github.EnterpriseEndpoints(cfg.WebURL, cfg.APIURL, cfg.UploadURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if !cfg.App.Enabled() {
+		return github.NewClient(cfg.Token, github.WithEndpoints(endpoints)), nil
+	}
+
+	key, err := cfg.App.LoadPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return github.NewAppClient(cfg.App.ID, key, github.WithEndpoints(endpoints))
+}
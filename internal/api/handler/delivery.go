@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type DeliveryHandler struct {
+	service *service.WebhookService
+}
+
+func NewDeliveryHandler(service *service.WebhookService) *DeliveryHandler {
+	return &DeliveryHandler{
+		service: service,
+	}
+}
+
+// GetDeliveries handles listing webhook deliveries, failed ones by default
+func (h *DeliveryHandler) GetDeliveries(c *gin.Context) {
+	status := model.DeliveryStatus(c.DefaultQuery("status", string(model.DeliveryFailed)))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDeliveryLimit)))
+	if err != nil || limit < 1 || limit > maxDeliveryLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveryLimit),
+			"code":  codeInvalidRequest,
+		})
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(c.Request.Context(), status, limit)
+	if err != nil {
+		status, code, message := classify(c, "Failed to fetch deliveries", err)
+		c.JSON(status, gin.H{
+			"error": message,
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// ReplayDelivery handles reprocessing a failed webhook delivery
+func (h *DeliveryHandler) ReplayDelivery(c *gin.Context) {
+	delivery, err := h.service.ReplayDelivery(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status, code, message := classify(c, "Failed to replay delivery", err)
+		response := gin.H{
+			"error": message,
+			"code":  code,
+		}
+		// The delivery shows how far the replay got
+		if delivery != nil {
+			response["delivery"] = delivery
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Delivery replayed successfully",
+		"delivery": delivery,
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/service"
+	"git-gud-bot/pkg/github"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes for failures detected by the handlers themselves. Service
+// errors carry their own codes.
+const (
+	codeInvalidRequest   = "invalid_request"
+	codeETagRequired     = "etag_required"
+	codeInternal         = "internal_error"
+	codeSignatureInvalid = "invalid_signature"
+
+	codeWebhookNotConfigured = "webhook_not_configured"
+)
+
+var errorStatus = map[service.Kind]int{
+	service.KindInvalidRequest: http.StatusBadRequest,
+	service.KindNotFound:       http.StatusNotFound,
+	service.KindConflict:       http.StatusConflict,
+	service.KindValidation:     http.StatusUnprocessableEntity,
+	service.KindUpstream:       http.StatusBadGateway,
+	service.KindRateLimited:    http.StatusTooManyRequests,
+}
+
+// classify returns the HTTP status, error code and client-safe message for
+// err. Errors the service did not classify are logged and reported only as
+// failed, so database and driver messages never reach clients. The causes
+// of classified errors are left out of the message for the same reason.
+func classify(c *gin.Context, failed string, err error) (int, string, string) {
+	var svcErr *service.Error
+	if !errors.As(err, &svcErr) || errorStatus[svcErr.Kind] == 0 {
+		log.Printf("%s %s: %s: %v", c.Request.Method, c.Request.URL.Path, failed, err)
+		return http.StatusInternalServerError, codeInternal, failed
+	}
+
+	if svcErr.Kind == service.KindRateLimited {
+		setRetryAfter(c, err)
+	}
+
+	message := err.Error()
+	if svcErr.Err != nil {
+		message = svcErr.Message
+	}
+	return errorStatus[svcErr.Kind], svcErr.Code, message
+}
+
+// writeError answers with the ReviewResponse for err. failed describes the
+// operation, e.g. "Failed to fetch review", for errors that are not shown.
+func writeError(c *gin.Context, failed string, err error) {
+	status, code, message := classify(c, failed, err)
+	c.JSON(status, model.ReviewResponse{
+		Code:  code,
+		Error: message,
+	})
+}
+
+// setRetryAfter tells the client when GitHub's rate limit resets
+func setRetryAfter(c *gin.Context, err error) {
+	var rateErr *github.RateLimitError
+	if !errors.As(err, &rateErr) {
+		return
+	}
+	seconds := math.Ceil(time.Until(rateErr.Reset).Seconds())
+	if seconds > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(seconds)))
+	}
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"git-gud-bot/internal/api/middleware"
 	"git-gud-bot/internal/model"
 	"git-gud-bot/internal/service"
 
@@ -10,20 +14,21 @@ import (
 )
 
 type ReviewHandler struct {
-	service *service.ReviewService
+	service service.ReviewServicer
 }
 
-func NewReviewHandler(service *service.ReviewService) *ReviewHandler {
+func NewReviewHandler(service service.ReviewServicer) *ReviewHandler {
 	return &ReviewHandler{
 		service: service,
 	}
 }
 
-// CreateReview handles the creation of a new code review
+// CreateReview handles queueing a new code review for analysis
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	var req model.ReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
 			Error: "Invalid request format: " + err.Error(),
 		})
 		return
@@ -31,15 +36,13 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 
 	review, err := h.service.CreateReview(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ReviewResponse{
-			Error: "Failed to create review: " + err.Error(),
-		})
+		writeError(c, "Failed to create review", err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, model.ReviewResponse{
+	c.JSON(http.StatusAccepted, model.ReviewResponse{
 		Review:  review,
-		Message: "Review created successfully",
+		Message: "Review queued for analysis",
 	})
 }
 
@@ -48,36 +51,171 @@ func (h *ReviewHandler) GetReview(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
 			Error: "Review ID is required",
 		})
 		return
 	}
 
-	review, err := h.service.GetReview(c.Request.Context(), id)
+	include, err := parseInclude(c.Query("include"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ReviewResponse{
-			Error: "Failed to fetch review: " + err.Error(),
+		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
+			Error: err.Error(),
 		})
 		return
 	}
 
+	review, err := h.service.GetReview(c.Request.Context(), id, include)
+	if err != nil {
+		writeError(c, "Failed to fetch review", err)
+		return
+	}
+
+	c.Header("ETag", review.ETag())
 	c.JSON(http.StatusOK, model.ReviewResponse{
 		Review: review,
 	})
 }
 
-// GetReviews handles fetching all reviews
-func (h *ReviewHandler) GetReviews(c *gin.Context) {
-	reviews, err := h.service.GetReviews(c.Request.Context())
+// UpdateReview handles partial updates of a review guarded by If-Match
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req model.ReviewUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
+			Error: "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	review, err := h.service.UpdateReview(c.Request.Context(), c.Param("id"), version, &req, actor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ReviewResponse{
-			Error: "Failed to fetch reviews: " + err.Error(),
+		writeError(c, "Failed to update review", err)
+		return
+	}
+
+	c.Header("ETag", review.ETag())
+	c.JSON(http.StatusOK, model.ReviewResponse{
+		Review:  review,
+		Message: "Review updated successfully",
+	})
+}
+
+// OverrideReview handles a human reviewer replacing the bot's status
+func (h *ReviewHandler) OverrideReview(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req model.ReviewOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
+			Error: "Invalid request format: " + err.Error(),
 		})
 		return
 	}
 
+	review, err := h.service.OverrideReview(c.Request.Context(), c.Param("id"), version, &req, actor(c))
+	if err != nil {
+		writeError(c, "Failed to override review", err)
+		return
+	}
+
+	c.Header("ETag", review.ETag())
+	c.JSON(http.StatusOK, model.ReviewResponse{
+		Review:  review,
+		Message: "Review status overridden",
+	})
+}
+
+// ifMatchVersion reads the review version from the If-Match header, writing
+// an error response and returning false if it is missing or malformed
+func ifMatchVersion(c *gin.Context) (time.Time, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, model.ReviewResponse{
+			Code:  codeETagRequired,
+			Error: "If-Match header with the review ETag is required",
+		})
+		return time.Time{}, false
+	}
+
+	version, err := model.ParseETag(header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
+			Error: "Invalid If-Match header: " + err.Error(),
+		})
+		return time.Time{}, false
+	}
+
+	return version, true
+}
+
+// actor returns the authenticated client name set by the auth middleware
+func actor(c *gin.Context) string {
+	if name := c.GetString(middleware.ActorKey); name != "" {
+		return name
+	}
+	return "api"
+}
+
+// GetReviewHistory handles fetching the status transitions of a review
+func (h *ReviewHandler) GetReviewHistory(c *gin.Context) {
+	history, err := h.service.GetStatusHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, "Failed to fetch review history", err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"reviews": reviews,
-		"count":   len(reviews),
+		"history": history,
+		"count":   len(history),
 	})
 }
+
+// GetReviews handles listing reviews one page at a time
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	opts, err := parseReviewListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ReviewResponse{
+			Code:  codeInvalidRequest,
+			Error: "Invalid query: " + err.Error(),
+		})
+		return
+	}
+
+	page, err := h.service.GetReviews(c.Request.Context(), opts)
+	if err != nil {
+		writeError(c, "Failed to fetch reviews", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseInclude reads a comma separated include parameter such as
+// "issues,metrics"
+func parseInclude(value string) (model.ReviewInclude, error) {
+	var include model.ReviewInclude
+	for _, name := range strings.Split(value, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "issues":
+			include.Issues = true
+		case "metrics":
+			include.Metrics = true
+		default:
+			return include, fmt.Errorf("unknown include %q; expected issues or metrics", name)
+		}
+	}
+	return include, nil
+}
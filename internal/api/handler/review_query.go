@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"git-gud-bot/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseReviewListOptions reads the filters, sort and page of GET /reviews:
+//
+//	host, repo_owner, repo_name, pr_number, status, commit_hash
+//	min_code_quality, max_code_quality (likewise performance, best_practices)
+//	created_after, created_before (RFC 3339)
+//	sort (created_at, updated_at, pr_number or a score), order (asc or desc)
+//	limit, cursor
+func parseReviewListOptions(c *gin.Context) (model.ReviewListOptions, error) {
+	opts := model.ReviewListOptions{
+		Filter: model.ReviewFilter{
+			Host:       c.Query("host"),
+			RepoOwner:  c.Query("repo_owner"),
+			RepoName:   c.Query("repo_name"),
+			Status:     model.ReviewStatus(c.Query("status")),
+			CommitHash: c.Query("commit_hash"),
+		},
+		Sort: model.ReviewSort(c.Query("sort")),
+	}
+
+	var err error
+	if opts.Filter.PRNumber, err = queryInt(c, "pr_number"); err != nil {
+		return opts, err
+	}
+	if opts.Limit, err = queryInt(c, "limit"); err != nil {
+		return opts, err
+	}
+
+	for _, score := range []struct {
+		name   string
+		bounds *model.ScoreRange
+	}{
+		{"code_quality", &opts.Filter.CodeQuality},
+		{"performance", &opts.Filter.Performance},
+		{"best_practices", &opts.Filter.BestPractices},
+	} {
+		if score.bounds.Min, err = queryFloat(c, "min_"+score.name); err != nil {
+			return opts, err
+		}
+		if score.bounds.Max, err = queryFloat(c, "max_"+score.name); err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.Filter.CreatedAfter, err = queryTime(c, "created_after"); err != nil {
+		return opts, err
+	}
+	if opts.Filter.CreatedBefore, err = queryTime(c, "created_before"); err != nil {
+		return opts, err
+	}
+
+	switch order := c.Query("order"); order {
+	case "":
+		// Newest first by default, ascending for any explicit sort field
+		opts.Descending = opts.Sort == ""
+	case "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		return opts, fmt.Errorf("order must be asc or desc, not %q", order)
+	}
+	if opts.Sort == "" {
+		opts.Sort = model.SortCreatedAt
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if opts.Cursor, err = model.DecodeCursor(cursor); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+func queryInt(c *gin.Context, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+	return n, nil
+}
+
+func queryFloat(c *gin.Context, key string) (*float64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &f, nil
+}
+
+func queryTime(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+	}
+	return &t, nil
+}
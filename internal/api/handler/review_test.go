@@ -2,42 +2,74 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-// MockReviewService is a mock implementation of the ReviewService
+// MockReviewService is a mock implementation of service.ReviewServicer
 type MockReviewService struct {
 	mock.Mock
 }
 
-func (m *MockReviewService) CreateReview(review *model.Review) error {
-	args := m.Called(review)
-	return args.Error(0)
+func (m *MockReviewService) CreateReview(ctx context.Context, req *model.ReviewRequest) (*model.Review, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Review), args.Error(1)
 }
 
-func (m *MockReviewService) GetReview(id string) (*model.Review, error) {
-	args := m.Called(id)
+func (m *MockReviewService) GetReview(ctx context.Context, id string, include model.ReviewInclude) (*model.Review, error) {
+	args := m.Called(id, include)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.Review), args.Error(1)
 }
 
-func (m *MockReviewService) GetReviews() ([]*model.Review, error) {
-	args := m.Called()
+func (m *MockReviewService) GetReviews(ctx context.Context, opts model.ReviewListOptions) (*model.ReviewPage, error) {
+	args := m.Called(opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReviewPage), args.Error(1)
+}
+
+func (m *MockReviewService) UpdateReview(ctx context.Context, id string, version time.Time, req *model.ReviewUpdateRequest, actor string) (*model.Review, error) {
+	args := m.Called(id, version, req, actor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Review), args.Error(1)
+}
+
+func (m *MockReviewService) OverrideReview(ctx context.Context, id string, version time.Time, req *model.ReviewOverrideRequest, actor string) (*model.Review, error) {
+	args := m.Called(id, version, req, actor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Review), args.Error(1)
+}
+
+func (m *MockReviewService) GetStatusHistory(ctx context.Context, id string) ([]*model.StatusTransition, error) {
+	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*model.Review), args.Error(1)
+	return args.Get(0).([]*model.StatusTransition), args.Error(1)
 }
 
 func setupTestRouter(h *ReviewHandler) *gin.Engine {
@@ -57,24 +89,37 @@ func TestCreateReview(t *testing.T) {
 	handler := NewReviewHandler(mockService)
 	router := setupTestRouter(handler)
 
+	req := &model.ReviewRequest{
+		PRNumber:   123,
+		RepoOwner:  "test-owner",
+		RepoName:   "test-repo",
+		CommitHash: "abc123",
+	}
 	review := &model.Review{
-		PRNumber: 123,
-		RepoName: "test-repo",
-		Status:   "pending",
-		Feedback: "Test feedback",
+		ID:         "test-id",
+		PRNumber:   123,
+		RepoOwner:  "test-owner",
+		RepoName:   "test-repo",
+		Status:     model.StatusPending,
+		CommitHash: "abc123",
 	}
 
-	mockService.On("CreateReview", mock.AnythingOfType("*model.Review")).Return(nil)
+	mockService.On("CreateReview", req).Return(review, nil)
 
-	body, _ := json.Marshal(review)
+	body, _ := json.Marshal(req)
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/reviews", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
+	httpReq, _ := http.NewRequest("POST", "/reviews", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	router.ServeHTTP(w, req)
+	router.ServeHTTP(w, httpReq)
 
-	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusAccepted, w.Code)
 	mockService.AssertExpectations(t)
+
+	var response model.ReviewResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, review.ID, response.Review.ID)
 }
 
 func TestGetReview(t *testing.T) {
@@ -88,7 +133,7 @@ func TestGetReview(t *testing.T) {
 		RepoName: "test-repo",
 	}
 
-	mockService.On("GetReview", "test-id").Return(review, nil)
+	mockService.On("GetReview", "test-id", model.ReviewInclude{}).Return(review, nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/reviews/test-id", nil)
@@ -98,10 +143,12 @@ func TestGetReview(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockService.AssertExpectations(t)
 
-	var response map[string]*model.Review
+	assert.Equal(t, review.ETag(), w.Header().Get("ETag"))
+
+	var response model.ReviewResponse
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, review.ID, response["review"].ID)
+	assert.Equal(t, review.ID, response.Review.ID)
 }
 
 func TestGetReviews(t *testing.T) {
@@ -122,7 +169,8 @@ func TestGetReviews(t *testing.T) {
 		},
 	}
 
-	mockService.On("GetReviews").Return(reviews, nil)
+	opts := model.ReviewListOptions{Sort: model.SortCreatedAt, Descending: true}
+	mockService.On("GetReviews", opts).Return(&model.ReviewPage{Reviews: reviews, Count: len(reviews)}, nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/reviews", nil)
@@ -137,3 +185,54 @@ func TestGetReviews(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, response["reviews"].([]interface{}), 2)
 }
+
+func TestGetReviewErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		want   string
+	}{
+		{
+			name:   "not found",
+			err:    service.ErrReviewNotFound,
+			status: http.StatusNotFound,
+			code:   "review_not_found",
+			want:   "review not found",
+		},
+		{
+			name:   "rate limited",
+			err:    fmt.Errorf("load: %w", &service.Error{Kind: service.KindRateLimited, Code: "github_rate_limited", Message: "slow down", Err: errors.New("secret detail")}),
+			status: http.StatusTooManyRequests,
+			code:   "github_rate_limited",
+			want:   "slow down",
+		},
+		{
+			name:   "driver errors stay internal",
+			err:    errors.New(`pq: relation "reviews" does not exist`),
+			status: http.StatusInternalServerError,
+			code:   "internal_error",
+			want:   "Failed to fetch review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockReviewService)
+			router := setupTestRouter(NewReviewHandler(mockService))
+			mockService.On("GetReview", "test-id", model.ReviewInclude{}).Return(nil, tt.err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/reviews/test-id", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+
+			var response model.ReviewResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Code)
+			assert.Equal(t, tt.want, response.Error)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"git-gud-bot/internal/service"
+	"git-gud-bot/pkg/github"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GitHub caps webhook payloads at 25 MB
+const maxWebhookPayloadSize = 25 << 20
+
+type WebhookHandler struct {
+	service *service.WebhookService
+	secret  []byte
+}
+
+func NewWebhookHandler(service *service.WebhookService, secret string) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		secret:  []byte(secret),
+	}
+}
+
+// HandleGithubWebhook handles signed webhook deliveries from GitHub
+func (h *WebhookHandler) HandleGithubWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookPayloadSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read request body",
+			"code":  codeInvalidRequest,
+		})
+		return
+	}
+
+	if err := github.ValidateSignature(payload, c.GetHeader("X-Hub-Signature-256"), h.secret); err != nil {
+		status, code := http.StatusUnauthorized, codeSignatureInvalid
+		if errors.Is(err, github.ErrMissingSecret) {
+			status, code = http.StatusServiceUnavailable, codeWebhookNotConfigured
+		}
+		c.JSON(status, gin.H{
+			"error": "Webhook signature verification failed: " + err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	event := c.GetHeader("X-GitHub-Event")
+	if event == github.EventPing {
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+		return
+	}
+
+	delivery, err := h.service.HandleDelivery(
+		c.Request.Context(),
+		c.GetHeader("X-GitHub-Delivery"),
+		c.GetHeader("X-GitHub-Enterprise-Host"),
+		event,
+		payload,
+	)
+	switch {
+	case errors.Is(err, service.ErrDuplicateDelivery):
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Delivery already received",
+			"status":  delivery.Status,
+		})
+		return
+	case err != nil:
+		status, code, message := classify(c, "Failed to process webhook", err)
+		c.JSON(status, gin.H{
+			"error": message,
+			"code":  code,
+		})
+		return
+	}
+
+	if delivery.ReviewID == "" {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Review queued for analysis",
+		"review_id": delivery.ReviewID,
+	})
+}
@@ -7,17 +7,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ActorKey is the context key holding the name of the authenticated client
+const ActorKey = "actor"
+
 type AuthMiddleware struct {
 	// Add fields for token validation, etc.
-	validAPIKeys map[string]bool
+	validAPIKeys map[string]string
 }
 
 func NewAuthMiddleware() *AuthMiddleware {
 	// In a real application, these would come from a database or environment variables
 	// This is just a placeholder for development
-	validKeys := map[string]bool{
-		"development-token": true,
-		"test-token":        true,
+	validKeys := map[string]string{
+		"development-token": "development",
+		"test-token":        "test",
 	}
 
 	return &AuthMiddleware{
@@ -50,7 +53,8 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		token := parts[1]
 
 		// Validate the token
-		if !m.validateToken(token) {
+		actor, ok := m.validateToken(token)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid or expired token",
 			})
@@ -58,16 +62,17 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 			return
 		}
 
-		// Store user info in context if needed
-		// c.Set("user_id", "some-user-id")
+		// Store who is calling for audit trails
+		c.Set(ActorKey, actor)
 
 		c.Next()
 	}
 }
 
-func (m *AuthMiddleware) validateToken(token string) bool {
+func (m *AuthMiddleware) validateToken(token string) (string, bool) {
 	// For development/testing, just check if it's in our valid keys map
-	return m.validAPIKeys[token]
+	actor, ok := m.validAPIKeys[token]
+	return actor, ok
 
 	// In production, you would:
 	// 1. Validate JWT token
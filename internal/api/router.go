@@ -8,14 +8,23 @@ import (
 )
 
 type Router struct {
-	handler    *handler.ReviewHandler
-	middleware *middleware.AuthMiddleware
+	handler         *handler.ReviewHandler
+	webhookHandler  *handler.WebhookHandler
+	deliveryHandler *handler.DeliveryHandler
+	middleware      *middleware.AuthMiddleware
 }
 
-func NewRouter(handler *handler.ReviewHandler, middleware *middleware.AuthMiddleware) *Router {
+func NewRouter(
+	handler *handler.ReviewHandler,
+	webhookHandler *handler.WebhookHandler,
+	deliveryHandler *handler.DeliveryHandler,
+	middleware *middleware.AuthMiddleware,
+) *Router {
 	return &Router{
-		handler:    handler,
-		middleware: middleware,
+		handler:         handler,
+		webhookHandler:  webhookHandler,
+		deliveryHandler: deliveryHandler,
+		middleware:      middleware,
 	}
 }
 
@@ -32,6 +41,12 @@ func (r *Router) Setup(engine *gin.Engine) {
 			public.GET("/status", r.getAPIStatus)
 		}
 
+		// GitHub webhook endpoints (authenticated by payload signature)
+		webhooks := v1.Group("/webhooks")
+		{
+			webhooks.POST("/github", r.webhookHandler.HandleGithubWebhook)
+		}
+
 		// Protected routes (auth required)
 		protected := v1.Group("/")
 		protected.Use(r.middleware.Authenticate())
@@ -42,6 +57,9 @@ func (r *Router) Setup(engine *gin.Engine) {
 				reviews.POST("/", r.handler.CreateReview)
 				reviews.GET("/", r.handler.GetReviews)
 				reviews.GET("/:id", r.handler.GetReview)
+				reviews.PATCH("/:id", r.handler.UpdateReview)
+				reviews.POST("/:id/override", r.handler.OverrideReview)
+				reviews.GET("/:id/history", r.handler.GetReviewHistory)
 			}
 
 			// Analysis endpoints
@@ -52,10 +70,11 @@ func (r *Router) Setup(engine *gin.Engine) {
 				analysis.GET("/reports", r.getReports)
 			}
 
-			// GitHub webhook endpoints
-			webhooks := protected.Group("/webhooks")
+			// Admin endpoints
+			admin := protected.Group("/admin")
 			{
-				webhooks.POST("/github", r.handleGithubWebhook)
+				admin.GET("/deliveries", r.deliveryHandler.GetDeliveries)
+				admin.POST("/deliveries/:id/replay", r.deliveryHandler.ReplayDelivery)
 			}
 
 			// User management endpoints
@@ -102,12 +121,6 @@ func (r *Router) getReports(c *gin.Context) {
 	c.JSON(501, gin.H{"message": "Not implemented yet"})
 }
 
-// GitHub webhook handler
-func (r *Router) handleGithubWebhook(c *gin.Context) {
-	// TODO: Implement GitHub webhook handler
-	c.JSON(501, gin.H{"message": "Not implemented yet"})
-}
-
 // User handlers
 func (r *Router) getCurrentUser(c *gin.Context) {
 	// TODO: Implement get current user endpoint
@@ -1,27 +1,236 @@
 package config
 
 import (
-	"database/sql"
+	"errors"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
 type Config struct {
-	Port        string
-	DB          *sql.DB
-	GithubToken string
+	Port          string
+	Database      DatabaseConfig
+	GithubHosts   []GithubHostConfig
+	WebhookSecret string
+	Worker        WorkerConfig
+}
+
+// GithubHostConfig is a GitHub instance the bot serves. The first host is
+// always github.com; GitHub Enterprise Server instances follow in the order
+// GITHUB_HOSTS lists them.
+type GithubHostConfig struct {
+	Name string
+	// WebURL is empty for github.com. The API and upload URLs default to the
+	// usual GitHub Enterprise Server paths below it.
+	WebURL    string
+	APIURL    string
+	UploadURL string
+	Token     string
+	App       GithubAppConfig
+}
+
+// GithubAppConfig authenticates as a GitHub App instead of with a token
+// when ID is set. PrivateKey holds the PEM contents; PrivateKeyPath is read
+// when it is empty.
+type GithubAppConfig struct {
+	ID             int64
+	PrivateKey     string
+	PrivateKeyPath string
+}
+
+// Enabled reports whether GitHub App credentials were configured
+func (c GithubAppConfig) Enabled() bool {
+	return c.ID != 0
+}
+
+// LoadPrivateKey returns the app's PEM encoded private key
+func (c GithubAppConfig) LoadPrivateKey() ([]byte, error) {
+	if c.PrivateKey != "" {
+		return []byte(c.PrivateKey), nil
+	}
+	if c.PrivateKeyPath == "" {
+		return nil, errors.New("a private key is required to authenticate as a GitHub App")
+	}
+	return os.ReadFile(c.PrivateKeyPath)
+}
+
+// Store drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// DatabaseConfig picks where data is stored. Postgres is located either by
+// DATABASE_URL or by the discrete DB_* variables; the remaining fields size
+// its connection pool. SQLitePath is only used by the sqlite driver.
+type DatabaseConfig struct {
+	Driver     string
+	SQLitePath string
+
+	URL      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+}
+
+// DSN returns DATABASE_URL when set, otherwise a URL built from the parts
+func (c DatabaseConfig) DSN() string {
+	if c.URL != "" {
+		return c.URL
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     c.Host + ":" + c.Port,
+		Path:     "/" + c.Name,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	if c.Password == "" {
+		u.User = url.User(c.User)
+	}
+	return u.String()
+}
+
+type WorkerConfig struct {
+	Count        int
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration
+	// DrainTimeout is how long running jobs may take to finish at shutdown
+	// before they are cancelled and requeued
+	DrainTimeout time.Duration
+}
+
+// Validate rejects settings the worker pool can't run with
+func (c WorkerConfig) Validate() error {
+	switch {
+	case c.Count < 1:
+		return errors.New("WORKER_COUNT must be at least 1")
+	case c.PollInterval <= 0:
+		return errors.New("WORKER_POLL_INTERVAL must be positive")
+	case c.MaxAttempts < 1:
+		return errors.New("JOB_MAX_ATTEMPTS must be at least 1")
+	case c.BaseBackoff <= 0 || c.MaxBackoff <= 0:
+		return errors.New("JOB_BASE_BACKOFF and JOB_MAX_BACKOFF must be positive")
+	case c.Lease <= 0:
+		return errors.New("JOB_LEASE must be positive")
+	case c.DrainTimeout < 0:
+		return errors.New("WORKER_DRAIN_TIMEOUT must not be negative")
+	}
+	return nil
 }
 
 func New() *Config {
 	return &Config{
-		Port:        getEnv("PORT", "8080"),
-		GithubToken: getEnv("GITHUB_TOKEN", ""),
-		DB:          nil, // We'll implement DB connection later
+		Port:          getEnv("PORT", "8080"),
+		GithubHosts:   githubHosts(),
+		WebhookSecret: getEnv("GITHUB_WEBHOOK_SECRET", ""),
+		Database: DatabaseConfig{
+			Driver:          getEnv("DB_DRIVER", DriverPostgres),
+			SQLitePath:      getEnv("DB_SQLITE_PATH", "gitgud.db"),
+			URL:             getEnv("DATABASE_URL", ""),
+			Host:            getEnv("DB_HOST", "localhost"),
+			Port:            getEnv("DB_PORT", "5432"),
+			User:            getEnv("DB_USER", "postgres"),
+			Password:        getEnv("DB_PASSWORD", ""),
+			Name:            getEnv("DB_NAME", "gitgud"),
+			SSLMode:         getEnv("DB_SSLMODE", "disable"),
+			MaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			ConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			ConnectAttempts: getEnvInt("DB_CONNECT_ATTEMPTS", 10),
+			ConnectBackoff:  getEnvDuration("DB_CONNECT_BACKOFF", time.Second),
+		},
+		Worker: WorkerConfig{
+			Count:        getEnvInt("WORKER_COUNT", 4),
+			PollInterval: getEnvDuration("WORKER_POLL_INTERVAL", 2*time.Second),
+			MaxAttempts:  getEnvInt("JOB_MAX_ATTEMPTS", 5),
+			BaseBackoff:  getEnvDuration("JOB_BASE_BACKOFF", 10*time.Second),
+			MaxBackoff:   getEnvDuration("JOB_MAX_BACKOFF", 10*time.Minute),
+			Lease:        getEnvDuration("JOB_LEASE", 15*time.Minute),
+			DrainTimeout: getEnvDuration("WORKER_DRAIN_TIMEOUT", 20*time.Second),
+		},
+	}
+}
+
+// githubHosts reads github.com's credentials from GITHUB_* and those of each
+// host named in GITHUB_HOSTS from GITHUB_<NAME>_*
+func githubHosts() []GithubHostConfig {
+	hosts := []GithubHostConfig{githubHost("github.com", "GITHUB_")}
+
+	for _, name := range strings.Split(getEnv("GITHUB_HOSTS", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "GITHUB_" + envName(name) + "_"
+		host := githubHost(name, prefix)
+		host.WebURL = getEnv(prefix+"URL", "")
+		host.APIURL = getEnv(prefix+"API_URL", "")
+		host.UploadURL = getEnv(prefix+"UPLOAD_URL", "")
+		hosts = append(hosts, host)
+	}
+
+	return hosts
+}
+
+func githubHost(name, prefix string) GithubHostConfig {
+	return GithubHostConfig{
+		Name:  name,
+		Token: getEnv(prefix+"TOKEN", ""),
+		App: GithubAppConfig{
+			ID:             int64(getEnvInt(prefix+"APP_ID", 0)),
+			PrivateKey:     getEnv(prefix+"APP_PRIVATE_KEY", ""),
+			PrivateKeyPath: getEnv(prefix+"APP_PRIVATE_KEY_PATH", ""),
+		},
 	}
 }
 
+// envName turns a host name such as "ghe-eu" into "GHE_EU"
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
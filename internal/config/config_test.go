@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  DatabaseConfig
+		want string
+	}{
+		{
+			name: "DATABASE_URL wins",
+			cfg:  DatabaseConfig{URL: "postgres://u@db/app", Host: "ignored"},
+			want: "postgres://u@db/app",
+		},
+		{
+			name: "discrete parts are escaped",
+			cfg:  DatabaseConfig{Host: "db", Port: "5433", User: "bot", Password: "p@ss/word", Name: "gitgud", SSLMode: "require"},
+			want: "postgres://bot:p%40ss%2Fword@db:5433/gitgud?sslmode=require",
+		},
+		{
+			name: "no password",
+			cfg:  DatabaseConfig{Host: "localhost", Port: "5432", User: "postgres", Name: "gitgud", SSLMode: "disable"},
+			want: "postgres://postgres@localhost:5432/gitgud?sslmode=disable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.DSN())
+		})
+	}
+}
+
+func TestWorkerConfigValidate(t *testing.T) {
+	valid := WorkerConfig{
+		Count:        4,
+		PollInterval: 2 * time.Second,
+		MaxAttempts:  5,
+		BaseBackoff:  10 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+		Lease:        15 * time.Minute,
+	}
+	assert.NoError(t, valid.Validate())
+
+	for name, mutate := range map[string]func(*WorkerConfig){
+		"no workers":       func(c *WorkerConfig) { c.Count = 0 },
+		"zero interval":    func(c *WorkerConfig) { c.PollInterval = 0 },
+		"negative poll":    func(c *WorkerConfig) { c.PollInterval = -time.Second },
+		"no attempts":      func(c *WorkerConfig) { c.MaxAttempts = 0 },
+		"negative backoff": func(c *WorkerConfig) { c.MaxBackoff = -time.Second },
+		"no lease":         func(c *WorkerConfig) { c.Lease = 0 },
+		"negative drain":   func(c *WorkerConfig) { c.DrainTimeout = -time.Second },
+	} {
+		cfg := valid
+		mutate(&cfg)
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
@@ -0,0 +1,392 @@
+// Package migrate applies the versioned schema migrations embedded in the
+// binary. Each migration runs in its own transaction together with its
+// schema_migrations bookkeeping, so a failed migration leaves no trace.
+//
+// Postgres migrations live in sql/ and their SQLite translations in sqlite/,
+// with the same versions and names, so both stores share one schema history.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed sql/*.sql sqlite/*.sql
+var embedded embed.FS
+
+// lockKey identifies the advisory lock held while migrating, so two migrate
+// runs against the same database never interleave
+const lockKey int64 = 0x67697467756462 // "gitgudb"
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change and the SQL that reverts it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// dialect is where a database's migrations live and how the migrator keeps
+// its bookkeeping there
+type dialect struct {
+	dir string
+	// lock and unlock are empty when the database needs no migration lock
+	lock, unlock     string
+	createMigrations string
+	// foreignKeysOff and foreignKeysOn are run around each migration's
+	// transaction, and checkForeignKeys inside it lists rows left violating
+	// foreign keys
+	foreignKeysOff, foreignKeysOn string
+	checkForeignKeys              string
+}
+
+var postgresDialect = dialect{
+	dir:    "sql",
+	lock:   `SELECT pg_advisory_lock($1)`,
+	unlock: `SELECT pg_advisory_unlock($1)`,
+	createMigrations: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)
+	`,
+}
+
+// sqliteDialect has no lock: SQLite databases are opened by a single process
+// with a single connection. Foreign keys are off while migrating because
+// SQLite changes constraints by rebuilding tables, and dropping a referenced
+// table would otherwise cascade.
+var sqliteDialect = dialect{
+	dir: "sqlite",
+	createMigrations: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`,
+	foreignKeysOff:   `PRAGMA foreign_keys = OFF`,
+	foreignKeysOn:    `PRAGMA foreign_keys = ON`,
+	checkForeignKeys: `PRAGMA foreign_key_check`,
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// New returns a migrator for the Postgres migrations embedded in this binary
+func New(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, postgresDialect)
+}
+
+// NewSQLite returns a migrator for the SQLite translations of the embedded
+// migrations
+func NewSQLite(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, sqliteDialect)
+}
+
+func newMigrator(db *sql.DB, d dialect) (*Migrator, error) {
+	fsys, err := fs.Sub(embedded, d.dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    d,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads NNNN_name.up.sql and NNNN_name.down.sql pairs from the root of
+// fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, path := range paths {
+		match := fileName.FindStringSubmatch(path)
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.up.sql or NNNN_name.down.sql", path)
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the highest known version, or 0 without migrations
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down reverts the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		current := 0
+		for version := range applied {
+			if version > current {
+				current = version
+			}
+		}
+		if current == 0 {
+			return nil
+		}
+
+		target := 0
+		for _, migration := range m.migrations {
+			if migration.Version < current {
+				target = migration.Version
+			}
+		}
+		return m.migrate(ctx, conn, applied, target)
+	})
+}
+
+// Goto applies or reverts migrations until exactly those up to version are
+// applied. Goto(0) reverts everything.
+func (m *Migrator) Goto(ctx context.Context, version int) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, applied, version)
+	})
+}
+
+// Status lists every known migration and when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if at, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, applied map[int]time.Time, target int) error {
+	for version := range applied {
+		if !m.known(version) {
+			return fmt.Errorf("database has migration %d applied, which this binary does not know; use a newer build", version)
+		}
+	}
+
+	up, down := plan(m.migrations, applied, target)
+
+	for _, migration := range down {
+		if err := m.run(ctx, conn, migration.Down,
+			`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+			return fmt.Errorf("failed to revert %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	for _, migration := range up {
+		if err := m.run(ctx, conn, migration.Up,
+			`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to apply %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// plan returns the migrations to apply, in ascending order, and those to
+// revert, in descending order, to reach target
+func plan(migrations []Migration, applied map[int]time.Time, target int) (up, down []Migration) {
+	for _, migration := range migrations {
+		_, isApplied := applied[migration.Version]
+		switch {
+		case migration.Version <= target && !isApplied:
+			up = append(up, migration)
+		case migration.Version > target && isApplied:
+			down = append([]Migration{migration}, down...)
+		}
+	}
+	return up, down
+}
+
+// run executes a migration and its bookkeeping in a single transaction
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration, bookkeeping string, args ...interface{}) error {
+	if m.dialect.foreignKeysOff != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.foreignKeysOff); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), m.dialect.foreignKeysOn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	if err := m.checkForeignKeys(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkForeignKeys fails when a migration run with foreign keys off left
+// rows referencing nothing
+func (m *Migrator) checkForeignKeys(ctx context.Context, tx *sql.Tx) error {
+	if m.dialect.checkForeignKeys == "" {
+		return nil
+	}
+
+	rows, err := tx.QueryContext(ctx, m.dialect.checkForeignKeys)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return errors.New("migration leaves rows violating foreign keys")
+	}
+	return rows.Err()
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.lock, lockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), m.dialect.unlock, lockKey)
+	}
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createMigrations); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) known(version int) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
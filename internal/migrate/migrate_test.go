@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func loadDialect(t *testing.T, d dialect) []Migration {
+	fsys, err := fs.Sub(embedded, d.dir)
+	require.NoError(t, err)
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	return migrations
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations := loadDialect(t, postgresDialect)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "migration %s; versions must be consecutive", migration.Name)
+	}
+
+	// Every migration has a SQLite translation under the same version and name
+	translated := loadDialect(t, sqliteDialect)
+	require.Len(t, translated, len(migrations))
+	for i, migration := range translated {
+		assert.Equal(t, migrations[i].Version, migration.Version)
+		assert.Equal(t, migrations[i].Name, migration.Name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr bool
+	}{
+		{
+			name: "pairs",
+			files: fstest.MapFS{
+				"0002_b.up.sql":   {Data: []byte("b")},
+				"0002_b.down.sql": {Data: []byte("b")},
+				"0001_a.up.sql":   {Data: []byte("a")},
+				"0001_a.down.sql": {Data: []byte("a")},
+			},
+		},
+		{
+			name:    "missing down",
+			files:   fstest.MapFS{"0001_a.up.sql": {Data: []byte("a")}},
+			wantErr: true,
+		},
+		{
+			name:    "bad name",
+			files:   fstest.MapFS{"create_a.sql": {Data: []byte("a")}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if assert.Len(t, migrations, 2) {
+				assert.Equal(t, "a", migrations[0].Name)
+				assert.Equal(t, "b", migrations[1].Name)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	applied := func(versions ...int) map[int]time.Time {
+		m := make(map[int]time.Time)
+		for _, v := range versions {
+			m[v] = time.Now()
+		}
+		return m
+	}
+	versions := func(ms []Migration) []int {
+		var out []int
+		for _, m := range ms {
+			out = append(out, m.Version)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		applied  map[int]time.Time
+		target   int
+		wantUp   []int
+		wantDown []int
+	}{
+		{"fresh database", applied(), 3, []int{1, 2, 3}, nil},
+		{"partially applied", applied(1), 3, []int{2, 3}, nil},
+		{"roll back in reverse", applied(1, 2, 3), 1, nil, []int{3, 2}},
+		{"everything", applied(1, 2, 3), 0, nil, []int{3, 2, 1}},
+		{"fill a gap", applied(1, 3), 3, []int{2}, nil},
+		{"already there", applied(1, 2), 2, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down := plan(migrations, tt.applied, tt.target)
+			assert.Equal(t, tt.wantUp, versions(up), "up")
+			assert.Equal(t, tt.wantDown, versions(down), "down")
+		})
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "gitgud.db")+"?_pragma=foreign_keys(1)&_time_format=sqlite")
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := NewSQLite(db)
+	require.NoError(t, err)
+
+	// Every down migration reverts its up migration
+	require.NoError(t, migrator.Up(ctx))
+	require.NoError(t, migrator.Goto(ctx, 0))
+	require.NoError(t, migrator.Up(ctx))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "migration %d", status.Version)
+	}
+}
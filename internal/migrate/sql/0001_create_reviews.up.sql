@@ -0,0 +1,21 @@
+CREATE TABLE reviews (
+    id             TEXT PRIMARY KEY,
+    host           TEXT NOT NULL DEFAULT 'github.com',
+    pr_number      INTEGER NOT NULL,
+    repo_owner     TEXT NOT NULL,
+    repo_name      TEXT NOT NULL,
+    status         TEXT NOT NULL
+        CHECK (status IN ('pending', 'approved', 'rejected', 'needs_work')),
+    title          TEXT NOT NULL DEFAULT '',
+    description    TEXT NOT NULL DEFAULT '',
+    feedback       TEXT NOT NULL DEFAULT '',
+    commit_hash    TEXT NOT NULL,
+    code_quality   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    performance    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    best_practices DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX reviews_created_at_idx ON reviews (created_at DESC);
+CREATE INDEX reviews_pull_request_idx ON reviews (host, repo_owner, repo_name, pr_number);
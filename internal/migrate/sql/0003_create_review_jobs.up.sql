@@ -0,0 +1,16 @@
+CREATE TABLE review_jobs (
+    id         TEXT PRIMARY KEY,
+    review_id  TEXT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    status     TEXT NOT NULL
+        CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at     TIMESTAMPTZ NOT NULL,
+    locked_at  TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Workers claim queued jobs that are due and running jobs whose lease expired
+CREATE INDEX review_jobs_claim_idx ON review_jobs (status, run_at);
+CREATE INDEX review_jobs_review_id_idx ON review_jobs (review_id);
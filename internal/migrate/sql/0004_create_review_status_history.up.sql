@@ -0,0 +1,11 @@
+CREATE TABLE review_status_history (
+    id          TEXT PRIMARY KEY,
+    review_id   TEXT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status   TEXT NOT NULL,
+    actor       TEXT NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX review_status_history_review_idx ON review_status_history (review_id, created_at);
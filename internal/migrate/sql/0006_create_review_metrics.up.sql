@@ -0,0 +1,13 @@
+CREATE TABLE review_metrics (
+    id          TEXT PRIMARY KEY,
+    review_id   TEXT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    file        TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    function    TEXT NOT NULL DEFAULT '',
+    line        INTEGER NOT NULL DEFAULT 0,
+    value       DOUBLE PRECISION NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX review_metrics_review_id_idx ON review_metrics (review_id);
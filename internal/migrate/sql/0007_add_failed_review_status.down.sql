@@ -0,0 +1,5 @@
+UPDATE reviews SET status = 'pending' WHERE status = 'failed';
+
+ALTER TABLE reviews DROP CONSTRAINT reviews_status_check;
+ALTER TABLE reviews ADD CONSTRAINT reviews_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'needs_work'));
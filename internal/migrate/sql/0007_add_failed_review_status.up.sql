@@ -0,0 +1,3 @@
+ALTER TABLE reviews DROP CONSTRAINT reviews_status_check;
+ALTER TABLE reviews ADD CONSTRAINT reviews_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'needs_work', 'failed'));
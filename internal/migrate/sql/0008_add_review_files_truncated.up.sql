@@ -0,0 +1 @@
+ALTER TABLE reviews ADD COLUMN files_truncated BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,15 @@
+CREATE TABLE webhook_deliveries (
+    id         TEXT PRIMARY KEY,
+    host       TEXT NOT NULL DEFAULT 'github.com',
+    event      TEXT NOT NULL,
+    payload    BLOB NOT NULL, -- exactly as received, for replays
+    status     TEXT NOT NULL
+        CHECK (status IN ('received', 'processed', 'ignored', 'failed')),
+    error      TEXT NOT NULL DEFAULT '',
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    review_id  TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_status_idx ON webhook_deliveries (status, created_at DESC);
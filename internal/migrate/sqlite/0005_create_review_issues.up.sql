@@ -0,0 +1,14 @@
+CREATE TABLE review_issues (
+    id          TEXT PRIMARY KEY,
+    review_id   TEXT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    rule        TEXT NOT NULL,
+    file        TEXT NOT NULL DEFAULT '',
+    line        INTEGER NOT NULL DEFAULT 0,
+    type        TEXT NOT NULL,
+    category    TEXT NOT NULL,
+    severity    TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL
+);
+
+CREATE INDEX review_issues_review_id_idx ON review_issues (review_id);
@@ -0,0 +1,27 @@
+-- SQLite cannot alter a CHECK constraint, so reviews is rebuilt. Foreign
+-- keys are off while migrating, so rows referencing reviews are kept.
+CREATE TABLE reviews_new (
+    id             TEXT PRIMARY KEY,
+    host           TEXT NOT NULL DEFAULT 'github.com',
+    pr_number      INTEGER NOT NULL,
+    repo_owner     TEXT NOT NULL,
+    repo_name      TEXT NOT NULL,
+    status         TEXT NOT NULL
+        CHECK (status IN ('pending', 'approved', 'rejected', 'needs_work', 'failed')),
+    title          TEXT NOT NULL DEFAULT '',
+    description    TEXT NOT NULL DEFAULT '',
+    feedback       TEXT NOT NULL DEFAULT '',
+    commit_hash    TEXT NOT NULL,
+    code_quality   REAL NOT NULL DEFAULT 0,
+    performance    REAL NOT NULL DEFAULT 0,
+    best_practices REAL NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL
+);
+
+INSERT INTO reviews_new SELECT * FROM reviews;
+DROP TABLE reviews;
+ALTER TABLE reviews_new RENAME TO reviews;
+
+CREATE INDEX reviews_created_at_idx ON reviews (created_at DESC);
+CREATE INDEX reviews_pull_request_idx ON reviews (host, repo_owner, repo_name, pr_number);
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// ReviewIssue is a problem the analysis found, stored with its review
+type ReviewIssue struct {
+	ID          string    `json:"id"`
+	ReviewID    string    `json:"review_id"`
+	Rule        string    `json:"rule"`
+	File        string    `json:"file"`
+	Line        int       `json:"line"`
+	Type        string    `json:"type"`
+	Category    string    `json:"category"`
+	Severity    string    `json:"severity"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ReviewMetric is a measurement of a file, or of a function in it when
+// Function is set
+type ReviewMetric struct {
+	ID          string    `json:"id"`
+	ReviewID    string    `json:"review_id"`
+	File        string    `json:"file"`
+	Name        string    `json:"name"`
+	Function    string    `json:"function,omitempty"`
+	Line        int       `json:"line,omitempty"`
+	Value       float64   `json:"value"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ReviewInclude selects the related records returned with a review
+type ReviewInclude struct {
+	Issues  bool
+	Metrics bool
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryReceived  DeliveryStatus = "received"
+	DeliveryProcessed DeliveryStatus = "processed"
+	DeliveryIgnored   DeliveryStatus = "ignored"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a GitHub webhook delivery keyed by its X-GitHub-Delivery ID
+type WebhookDelivery struct {
+	ID        string          `json:"id"`
+	Host      string          `json:"host"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Status    DeliveryStatus  `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	Attempts  int             `json:"attempts"`
+	ReviewID  string          `json:"review_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// ReviewJob is a queued unit of work that fills in the analysis for a review
+type ReviewJob struct {
+	ID        string     `json:"id"`
+	ReviewID  string     `json:"review_id"`
+	Status    JobStatus  `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	RunAt     time.Time  `json:"run_at"`
+	LockedAt  *time.Time `json:"locked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Review list limits
+const (
+	DefaultReviewLimit = 50
+	MaxReviewLimit     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ReviewSort is a field reviews can be listed by. Only created_at and
+// pr_number never change; a review whose updated_at or scores change while a
+// client pages moves in the order, so it may be listed twice or not at all.
+type ReviewSort string
+
+const (
+	SortCreatedAt     ReviewSort = "created_at"
+	SortUpdatedAt     ReviewSort = "updated_at"
+	SortPRNumber      ReviewSort = "pr_number"
+	SortCodeQuality   ReviewSort = "code_quality"
+	SortPerformance   ReviewSort = "performance"
+	SortBestPractices ReviewSort = "best_practices"
+)
+
+// Valid reports whether reviews can be sorted by s
+func (s ReviewSort) Valid() bool {
+	switch s {
+	case SortCreatedAt, SortUpdatedAt, SortPRNumber, SortCodeQuality, SortPerformance, SortBestPractices:
+		return true
+	}
+	return false
+}
+
+// ScoreRange bounds a score inclusively; nil bounds are open
+type ScoreRange struct {
+	Min *float64
+	Max *float64
+}
+
+// ReviewFilter narrows a review listing. Zero fields do not filter.
+type ReviewFilter struct {
+	Host          string
+	RepoOwner     string
+	RepoName      string
+	PRNumber      int
+	Status        ReviewStatus
+	CommitHash    string
+	CodeQuality   ScoreRange
+	Performance   ScoreRange
+	BestPractices ScoreRange
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// ReviewListOptions selects one page of reviews. Pages are keyset based: the
+// cursor holds the sort value and ID of the last review of the previous page,
+// so rows inserted meanwhile never shift or repeat results. Rows updated
+// meanwhile can when sorted by a mutable field; see ReviewSort.
+type ReviewListOptions struct {
+	Filter     ReviewFilter
+	Sort       ReviewSort
+	Descending bool
+	Limit      int
+	Cursor     *ReviewCursor
+}
+
+// Validate checks the options and fills in defaults
+func (o *ReviewListOptions) Validate() error {
+	if o.Sort == "" {
+		o.Sort = SortCreatedAt
+		o.Descending = true
+	}
+	if !o.Sort.Valid() {
+		return fmt.Errorf("cannot sort by %q", o.Sort)
+	}
+
+	if o.Limit == 0 {
+		o.Limit = DefaultReviewLimit
+	}
+	if o.Limit < 1 || o.Limit > MaxReviewLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxReviewLimit)
+	}
+
+	if o.Filter.Status != "" && !o.Filter.Status.Valid() {
+		return fmt.Errorf("unknown status %q", o.Filter.Status)
+	}
+
+	if o.Cursor != nil && (o.Cursor.Sort != o.Sort || o.Cursor.Descending != o.Descending) {
+		return fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	return nil
+}
+
+// ReviewPage is one page of a review listing
+type ReviewPage struct {
+	Reviews    []*Review `json:"reviews"`
+	Count      int       `json:"count"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ReviewCursor marks the position after a review in a sorted listing
+type ReviewCursor struct {
+	Sort       ReviewSort `json:"s"`
+	Descending bool       `json:"d,omitempty"`
+	Value      string     `json:"v"`
+	ID         string     `json:"id"`
+}
+
+// CursorAfter returns the cursor that continues a listing after review
+func CursorAfter(review *Review, sort ReviewSort, descending bool) *ReviewCursor {
+	var value string
+	switch sort {
+	case SortCreatedAt:
+		value = strconv.FormatInt(review.CreatedAt.UnixMicro(), 10)
+	case SortUpdatedAt:
+		value = strconv.FormatInt(review.UpdatedAt.UnixMicro(), 10)
+	case SortPRNumber:
+		value = strconv.Itoa(review.PRNumber)
+	case SortCodeQuality:
+		value = strconv.FormatFloat(review.CodeQuality, 'g', -1, 64)
+	case SortPerformance:
+		value = strconv.FormatFloat(review.Performance, 'g', -1, 64)
+	case SortBestPractices:
+		value = strconv.FormatFloat(review.BestPractices, 'g', -1, 64)
+	}
+
+	return &ReviewCursor{Sort: sort, Descending: descending, Value: value, ID: review.ID}
+}
+
+// SortValue returns the cursor's value typed like the sort field: a time for
+// timestamps, an int for PR numbers and a float64 for scores
+func (c *ReviewCursor) SortValue() (interface{}, error) {
+	switch c.Sort {
+	case SortCreatedAt, SortUpdatedAt:
+		micros, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return time.UnixMicro(micros), nil
+	case SortPRNumber:
+		n, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return n, nil
+	case SortCodeQuality, SortPerformance, SortBestPractices:
+		f, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return f, nil
+	}
+	return nil, ErrInvalidCursor
+}
+
+// Encode returns the opaque form handed to API clients
+func (c *ReviewCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(s string) (*ReviewCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor ReviewCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := cursor.SortValue(); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// NewReviewPage builds a page from up to Limit+1 reviews fetched in order;
+// the extra review only signals that another page follows
+func NewReviewPage(reviews []*Review, opts ReviewListOptions) *ReviewPage {
+	page := &ReviewPage{Reviews: reviews}
+	if page.Reviews == nil {
+		page.Reviews = []*Review{}
+	}
+
+	if len(page.Reviews) > opts.Limit {
+		page.Reviews = page.Reviews[:opts.Limit]
+		last := page.Reviews[len(page.Reviews)-1]
+		page.NextCursor = CursorAfter(last, opts.Sort, opts.Descending).Encode()
+	}
+	page.Count = len(page.Reviews)
+
+	return page
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewCursorRoundTrip(t *testing.T) {
+	review := &Review{
+		ID:          "r-42",
+		PRNumber:    42,
+		CodeQuality: 87.5,
+		CreatedAt:   time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC),
+	}
+
+	cursor, err := DecodeCursor(CursorAfter(review, SortCreatedAt, true).Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, SortCreatedAt, cursor.Sort)
+	assert.True(t, cursor.Descending)
+	assert.Equal(t, "r-42", cursor.ID)
+	value, err := cursor.SortValue()
+	assert.NoError(t, err)
+	assert.True(t, value.(time.Time).Equal(review.CreatedAt.Truncate(time.Microsecond)))
+
+	cursor, err = DecodeCursor(CursorAfter(review, SortCodeQuality, false).Encode())
+	assert.NoError(t, err)
+	value, err = cursor.SortValue()
+	assert.NoError(t, err)
+	assert.Equal(t, 87.5, value)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = DecodeCursor((&ReviewCursor{Sort: SortPRNumber, Value: "abc", ID: "r-1"}).Encode())
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestReviewListOptionsValidate(t *testing.T) {
+	opts := ReviewListOptions{}
+	assert.NoError(t, opts.Validate())
+	assert.Equal(t, SortCreatedAt, opts.Sort)
+	assert.True(t, opts.Descending)
+	assert.Equal(t, DefaultReviewLimit, opts.Limit)
+
+	assert.Error(t, (&ReviewListOptions{Sort: "author"}).Validate())
+	assert.Error(t, (&ReviewListOptions{Limit: MaxReviewLimit + 1}).Validate())
+	assert.Error(t, (&ReviewListOptions{Filter: ReviewFilter{Status: "merged"}}).Validate())
+
+	opts = ReviewListOptions{
+		Sort:   SortPRNumber,
+		Cursor: &ReviewCursor{Sort: SortPRNumber, Descending: true, Value: "7", ID: "r-7"},
+	}
+	assert.ErrorIs(t, opts.Validate(), ErrInvalidCursor)
+}
+
+func TestNewReviewPage(t *testing.T) {
+	opts := ReviewListOptions{Sort: SortPRNumber, Limit: 2}
+
+	page := NewReviewPage(nil, opts)
+	assert.NotNil(t, page.Reviews)
+	assert.Equal(t, 0, page.Count)
+	assert.Empty(t, page.NextCursor)
+
+	reviews := []*Review{{ID: "a", PRNumber: 1}, {ID: "b", PRNumber: 2}, {ID: "c", PRNumber: 3}}
+	page = NewReviewPage(reviews, opts)
+	assert.Equal(t, 2, page.Count)
+	assert.Len(t, page.Reviews, 2)
+
+	cursor, err := DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", cursor.ID)
+	assert.Equal(t, "2", cursor.Value)
+
+	page = NewReviewPage(reviews[:2], opts)
+	assert.Empty(t, page.NextCursor)
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,10 +14,13 @@ const (
 	StatusApproved ReviewStatus = "approved"
 	StatusRejected ReviewStatus = "rejected"
 	StatusNeedWork ReviewStatus = "needs_work"
+	// StatusFailed marks a review the bot gave up analyzing
+	StatusFailed ReviewStatus = "failed"
 )
 
 type Review struct {
 	ID            string       `json:"id"`
+	Host          string       `json:"host"`
 	PRNumber      int          `json:"pr_number"`
 	RepoOwner     string       `json:"repo_owner"`
 	RepoName      string       `json:"repo_name"`
@@ -26,19 +32,67 @@ type Review struct {
 	CodeQuality   float64      `json:"code_quality"`
 	Performance   float64      `json:"performance"`
 	BestPractices float64      `json:"best_practices"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
+	// FilesTruncated is set when the pull request had more changed files
+	// than GitHub lists, so the analysis skipped the rest
+	FilesTruncated bool      `json:"files_truncated"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Issues and Metrics are only loaded when requested
+	Issues  []ReviewIssue  `json:"issues,omitempty"`
+	Metrics []ReviewMetric `json:"metrics,omitempty"`
 }
 
 type ReviewRequest struct {
+	// Host names the GitHub instance the repository lives on; empty means
+	// github.com
+	Host       string `json:"host"`
 	PRNumber   int    `json:"pr_number" binding:"required"`
 	RepoOwner  string `json:"repo_owner" binding:"required"`
 	RepoName   string `json:"repo_name" binding:"required"`
 	CommitHash string `json:"commit_hash" binding:"required"`
 }
 
+// ReviewUpdateRequest changes editable fields of a review. Omitted fields
+// are left unchanged.
+type ReviewUpdateRequest struct {
+	Status   *ReviewStatus `json:"status"`
+	Feedback *string       `json:"feedback"`
+	Reason   string        `json:"reason"`
+}
+
+// ReviewOverrideRequest replaces the bot's decision with a human one
+type ReviewOverrideRequest struct {
+	Status        ReviewStatus `json:"status" binding:"required"`
+	Justification string       `json:"justification" binding:"required"`
+}
+
 type ReviewResponse struct {
 	Review  *Review `json:"review"`
 	Message string  `json:"message,omitempty"`
-	Error   string  `json:"error,omitempty"`
+	// Code identifies the error for programs; unlike Error, it never changes
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ETag identifies the version of a review for optimistic concurrency. It is
+// derived from UpdatedAt at the microsecond precision the database keeps.
+func (r *Review) ETag() string {
+	return strconv.Quote(strconv.FormatInt(r.UpdatedAt.UnixMicro(), 10))
+}
+
+// ParseETag returns the UpdatedAt version encoded in an ETag or If-Match value
+func ParseETag(etag string) (time.Time, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	value, err := strconv.Unquote(etag)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed ETag %q", etag)
+	}
+
+	micros, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed ETag %q", etag)
+	}
+
+	return time.UnixMicro(micros), nil
 }
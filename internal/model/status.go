@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+)
+
+// ActorBot is recorded as the actor for transitions made by the analysis
+const ActorBot = "git-gud-bot"
+
+// statusTransitions lists the statuses each status may move to. Pending is
+// only ever the initial status; a new commit gets a new review. Failed is
+// only set when analysis gives up, and only an override leaves it.
+var statusTransitions = map[ReviewStatus][]ReviewStatus{
+	StatusPending:  {StatusApproved, StatusNeedWork, StatusRejected},
+	StatusNeedWork: {StatusApproved, StatusRejected},
+	StatusApproved: {StatusNeedWork, StatusRejected},
+	StatusRejected: {StatusNeedWork},
+	StatusFailed:   {},
+}
+
+func (s ReviewStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// Decided reports whether the status is a verdict on the code, as opposed to
+// pending or failed analysis
+func (s ReviewStatus) Decided() bool {
+	switch s {
+	case StatusApproved, StatusNeedWork, StatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanTransitionTo reports whether a review may move from s to the given status
+func (s ReviewStatus) CanTransitionTo(to ReviewStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusTransition is a recorded change of a review's status
+type StatusTransition struct {
+	ID         string       `json:"id"`
+	ReviewID   string       `json:"review_id"`
+	FromStatus ReviewStatus `json:"from_status"`
+	ToStatus   ReviewStatus `json:"to_status"`
+	Actor      string       `json:"actor"`
+	Reason     string       `json:"reason"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	assert.True(t, StatusPending.CanTransitionTo(StatusApproved))
+	assert.True(t, StatusPending.CanTransitionTo(StatusRejected))
+	assert.True(t, StatusNeedWork.CanTransitionTo(StatusApproved))
+	assert.True(t, StatusRejected.CanTransitionTo(StatusNeedWork))
+
+	assert.False(t, StatusApproved.CanTransitionTo(StatusPending))
+	assert.False(t, StatusRejected.CanTransitionTo(StatusApproved))
+	assert.False(t, StatusApproved.CanTransitionTo(StatusApproved))
+	assert.False(t, ReviewStatus("merged").CanTransitionTo(StatusApproved))
+
+	assert.False(t, StatusPending.CanTransitionTo(StatusFailed))
+	assert.False(t, StatusFailed.CanTransitionTo(StatusApproved))
+
+	assert.True(t, StatusNeedWork.Valid())
+	assert.True(t, StatusFailed.Valid())
+	assert.False(t, ReviewStatus("merged").Valid())
+
+	assert.True(t, StatusRejected.Decided())
+	assert.False(t, StatusPending.Decided())
+	assert.False(t, StatusFailed.Decided())
+}
+
+func TestReviewETag(t *testing.T) {
+	review := &Review{UpdatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)}
+
+	version, err := ParseETag(review.ETag())
+	assert.NoError(t, err)
+	assert.True(t, version.Equal(review.UpdatedAt.Truncate(time.Microsecond)))
+
+	version, err = ParseETag("W/" + review.ETag())
+	assert.NoError(t, err)
+	assert.True(t, version.Equal(review.UpdatedAt.Truncate(time.Microsecond)))
+
+	_, err = ParseETag("not-quoted")
+	assert.Error(t, err)
+	_, err = ParseETag(`"abc"`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package repoconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"git-gud-bot/pkg/analyzer"
+	"git-gud-bot/pkg/github"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is read from the base branch of every reviewed pull request
+const FileName = ".gitgud.yml"
+
+// Config is a repository's .gitgud.yml
+type Config struct {
+	Rules      RulesConfig       `yaml:"rules"`
+	Severity   map[string]string `yaml:"severity"`
+	Ignore     []string          `yaml:"ignore"`
+	Thresholds Thresholds        `yaml:"thresholds"`
+	Comments   CommentsConfig    `yaml:"comments"`
+	Checks     ChecksConfig      `yaml:"checks"`
+}
+
+type RulesConfig struct {
+	Enable  []string `yaml:"enable"`
+	Disable []string `yaml:"disable"`
+}
+
+// Thresholds are the lowest score, across all three categories, that still
+// earns each review status
+type Thresholds struct {
+	Approve   float64 `yaml:"approve"`
+	NeedsWork float64 `yaml:"needs_work"`
+}
+
+type CommentsConfig struct {
+	Inline bool `yaml:"inline"`
+}
+
+// ChecksConfig picks the check run conclusion for reviews that need work,
+// failure or action_required. Branch protection blocks merging on either.
+type ChecksConfig struct {
+	NeedsWork string `yaml:"needs_work"`
+}
+
+// Default is used when a repository has no .gitgud.yml or an invalid one
+func Default() *Config {
+	return &Config{
+		Thresholds: Thresholds{
+			Approve:   80,
+			NeedsWork: 60,
+		},
+		Comments: CommentsConfig{
+			Inline: true,
+		},
+		Checks: ChecksConfig{
+			NeedsWork: github.CheckConclusionFailure,
+		},
+	}
+}
+
+// Parse decodes a .gitgud.yml on top of the defaults and validates it against
+// the rules known to the analyzer. Unknown keys are rejected so typos do not
+// silently fall back to defaults.
+func Parse(data []byte, knownRules []string) (*Config, error) {
+	cfg := Default()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	if err := cfg.Validate(knownRules); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) Validate(knownRules []string) error {
+	known := make(map[string]bool, len(knownRules))
+	for _, rule := range knownRules {
+		known[rule] = true
+	}
+
+	var errs []error
+	for _, rule := range append(append([]string{}, c.Rules.Enable...), c.Rules.Disable...) {
+		if !known[rule] {
+			errs = append(errs, fmt.Errorf("rules: unknown rule %q", rule))
+		}
+	}
+
+	rules := make([]string, 0, len(c.Severity))
+	for rule := range c.Severity {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		severity := c.Severity[rule]
+		if !known[rule] {
+			errs = append(errs, fmt.Errorf("severity: unknown rule %q", rule))
+		}
+		switch severity {
+		case analyzer.SeverityLow, analyzer.SeverityMedium, analyzer.SeverityHigh:
+		default:
+			errs = append(errs, fmt.Errorf("severity: %q for %s must be low, medium or high", severity, rule))
+		}
+	}
+
+	for _, pattern := range c.Ignore {
+		if !validPattern(pattern) {
+			errs = append(errs, fmt.Errorf("ignore: invalid pattern %q", pattern))
+		}
+	}
+
+	t := c.Thresholds
+	if t.Approve < 0 || t.Approve > 100 || t.NeedsWork < 0 || t.NeedsWork > 100 {
+		errs = append(errs, fmt.Errorf("thresholds: values must be between 0 and 100"))
+	}
+	if t.NeedsWork > t.Approve {
+		errs = append(errs, fmt.Errorf("thresholds: needs_work (%.0f) must not exceed approve (%.0f)", t.NeedsWork, t.Approve))
+	}
+
+	switch c.Checks.NeedsWork {
+	case github.CheckConclusionFailure, github.CheckConclusionActionRequired:
+	default:
+		errs = append(errs, fmt.Errorf("checks: needs_work %q must be failure or action_required", c.Checks.NeedsWork))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Ignored reports whether a file matches one of the ignore patterns
+func (c *Config) Ignored(name string) bool {
+	for _, pattern := range c.Ignore {
+		if MatchPath(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzerOptions converts the config into per-run analyzer options
+func (c *Config) AnalyzerOptions() analyzer.Options {
+	opts := analyzer.Options{
+		EnabledRules:      make(map[string]bool),
+		DisabledRules:     make(map[string]bool),
+		SeverityOverrides: c.Severity,
+		Ignore:            c.Ignored,
+	}
+	for _, rule := range c.Rules.Enable {
+		opts.EnabledRules[rule] = true
+	}
+	for _, rule := range c.Rules.Disable {
+		opts.DisabledRules[rule] = true
+	}
+	return opts
+}
@@ -0,0 +1,94 @@
+package repoconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var knownRules = []string{"go/ignored-error", "go/shadowed-err"}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`
+rules:
+  disable: [go/shadowed-err]
+severity:
+  go/ignored-error: low
+ignore:
+  - vendor/
+  - "**/*.pb.go"
+thresholds:
+  approve: 90
+comments:
+  inline: false
+checks:
+  needs_work: action_required
+`), knownRules)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"go/shadowed-err"}, cfg.Rules.Disable)
+	assert.Equal(t, 90.0, cfg.Thresholds.Approve)
+	assert.Equal(t, 60.0, cfg.Thresholds.NeedsWork)
+	assert.False(t, cfg.Comments.Inline)
+	assert.Equal(t, "action_required", cfg.Checks.NeedsWork)
+
+	opts := cfg.AnalyzerOptions()
+	assert.True(t, opts.DisabledRules["go/shadowed-err"])
+	assert.Equal(t, "low", opts.SeverityOverrides["go/ignored-error"])
+	assert.True(t, opts.Ignore("vendor/github.com/x/y.go"))
+	assert.True(t, opts.Ignore("api/v1/service.pb.go"))
+	assert.False(t, opts.Ignore("internal/service/review.go"))
+}
+
+func TestParseEmpty(t *testing.T) {
+	cfg, err := Parse(nil, knownRules)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]byte("rulez: {}"), knownRules)
+	assert.ErrorContains(t, err, "invalid YAML")
+
+	_, err = Parse([]byte(`
+rules:
+  disable: [go/nope]
+severity:
+  go/ignored-error: critical
+ignore: ["[bad"]
+thresholds:
+  approve: 50
+  needs_work: 70
+checks:
+  needs_work: neutral
+`), knownRules)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `unknown rule "go/nope"`)
+	assert.ErrorContains(t, err, `"critical"`)
+	assert.ErrorContains(t, err, `invalid pattern "[bad"`)
+	assert.ErrorContains(t, err, "needs_work (70) must not exceed approve (50)")
+	assert.ErrorContains(t, err, `needs_work "neutral" must be failure or action_required`)
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"vendor/**", "vendor/a/b.go", true},
+		{"vendor/", "vendor/a.go", true},
+		{"vendor/", "src/vendor/a.go", false},
+		{"**/vendor/**", "src/vendor/a.go", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/README.md", false},
+		{"**/*.md", "README.md", true},
+		{"docs/*/index.md", "docs/api/index.md", true},
+		{"docs/*/index.md", "docs/api/v1/index.md", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, MatchPath(c.pattern, c.name), "%s vs %s", c.pattern, c.name)
+	}
+}
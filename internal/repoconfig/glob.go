@@ -0,0 +1,50 @@
+package repoconfig
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPath reports whether a slash-separated file path matches a glob
+// pattern. In addition to path.Match syntax, a "**" segment matches any
+// number of directories and a trailing slash matches everything below a
+// directory, so "vendor/" and "vendor/**" are equivalent.
+func MatchPath(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try every possible number of directories for the wildcard
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validPattern reports whether every segment of a pattern is valid syntax
+func validPattern(pattern string) bool {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,9 @@
+package repository
+
+import (
+	"errors"
+)
+
+// ErrConflict is returned when a write is rejected because the row was
+// changed after the caller read it
+var ErrConflict = errors.New("record was modified concurrently")
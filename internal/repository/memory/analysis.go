@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) SaveAnalysis(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition, issues []model.ReviewIssue, metrics []model.ReviewMetric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.reviews[review.ID]; !ok || !stored.UpdatedAt.Equal(version) {
+		return repository.ErrConflict
+	}
+
+	s.updateReview(review, timestamp())
+	if transition != nil {
+		s.insertTransition(review, transition)
+	}
+
+	stored := make([]model.ReviewIssue, len(issues))
+	for i := range issues {
+		if issues[i].ID == "" {
+			issues[i].ID = uuid.New().String()
+		}
+		issues[i].ReviewID = review.ID
+		issues[i].CreatedAt = review.UpdatedAt
+		stored[i] = issues[i]
+	}
+	s.issues[review.ID] = stored
+
+	storedMetrics := make([]model.ReviewMetric, len(metrics))
+	for i := range metrics {
+		if metrics[i].ID == "" {
+			metrics[i].ID = uuid.New().String()
+		}
+		metrics[i].ReviewID = review.ID
+		metrics[i].CreatedAt = review.UpdatedAt
+		storedMetrics[i] = metrics[i]
+	}
+	s.metrics[review.ID] = storedMetrics
+
+	return nil
+}
+
+func (s *Store) GetIssues(ctx context.Context, reviewID string) ([]model.ReviewIssue, error) {
+	s.mu.Lock()
+	issues := append([]model.ReviewIssue{}, s.issues[reviewID]...)
+	s.mu.Unlock()
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Rule < b.Rule
+	})
+
+	return issues, nil
+}
+
+func (s *Store) GetMetrics(ctx context.Context, reviewID string) ([]model.ReviewMetric, error) {
+	s.mu.Lock()
+	metrics := append([]model.ReviewMetric{}, s.metrics[reviewID]...)
+	s.mu.Unlock()
+
+	sort.SliceStable(metrics, func(i, j int) bool {
+		a, b := metrics[i], metrics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Name < b.Name
+	})
+
+	return metrics, nil
+}
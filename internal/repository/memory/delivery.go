@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"git-gud-bot/internal/model"
+)
+
+func (s *Store) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	if _, ok := s.deliveries[delivery.ID]; ok {
+		return false, nil
+	}
+
+	copied := *delivery
+	copied.Payload = append([]byte(nil), delivery.Payload...)
+	s.deliveries[delivery.ID] = &copied
+	return true, nil
+}
+
+func (s *Store) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery.UpdatedAt = time.Now()
+
+	stored, ok := s.deliveries[delivery.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = delivery.Status
+	stored.Error = delivery.Error
+	stored.Attempts = delivery.Attempts
+	stored.ReviewID = delivery.ReviewID
+	stored.UpdatedAt = delivery.UpdatedAt
+
+	return nil
+}
+
+func (s *Store) ClaimDelivery(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return false, nil
+	}
+
+	stale := delivery.Status == model.DeliveryReceived && delivery.UpdatedAt.Before(staleBefore)
+	if delivery.Status != model.DeliveryFailed && !stale {
+		return false, nil
+	}
+
+	delivery.Status = model.DeliveryReceived
+	delivery.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *Store) GetDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	copied := *delivery
+	copied.Payload = append([]byte(nil), delivery.Payload...)
+	return &copied, nil
+}
+
+func (s *Store) GetDeliveries(ctx context.Context, status model.DeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	s.mu.Lock()
+	var deliveries []*model.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status != status {
+			continue
+		}
+		copied := *delivery
+		copied.Payload = nil
+		deliveries = append(deliveries, &copied)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git-gud-bot/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) EnqueueJob(ctx context.Context, job *model.ReviewJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertJob(job)
+}
+
+func (s *Store) ClaimJob(ctx context.Context, lease time.Duration) (*model.ReviewJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var next *model.ReviewJob
+	for _, job := range s.jobs {
+		runnable := (job.Status == model.JobQueued && !job.RunAt.After(now)) ||
+			(job.Status == model.JobRunning && job.LockedAt != nil && job.LockedAt.Before(now.Add(-lease)))
+		if !runnable {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) || (job.RunAt.Equal(next.RunAt) && job.ID < next.ID) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = model.JobRunning
+	next.Attempts++
+	next.LockedAt = &now
+	next.UpdatedAt = now
+
+	copied := *next
+	return &copied, nil
+}
+
+func (s *Store) CompleteJob(ctx context.Context, id string) error {
+	return s.finishJob(id, model.JobCompleted, "", time.Now())
+}
+
+func (s *Store) RetryJob(ctx context.Context, id string, runAt time.Time, lastError string) error {
+	return s.finishJob(id, model.JobQueued, lastError, runAt)
+}
+
+func (s *Store) FailJob(ctx context.Context, id string, lastError string) error {
+	return s.finishJob(id, model.JobFailed, lastError, time.Now())
+}
+
+func (s *Store) insertJob(job *model.ReviewJob) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	if _, ok := s.jobs[job.ID]; ok {
+		return fmt.Errorf("job %s already exists", job.ID)
+	}
+	if _, ok := s.reviews[job.ReviewID]; !ok {
+		return fmt.Errorf("job %s refers to unknown review %s", job.ID, job.ReviewID)
+	}
+
+	now := time.Now()
+	if job.Status == "" {
+		job.Status = model.JobQueued
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	copied := *job
+	s.jobs[job.ID] = &copied
+	return nil
+}
+
+func (s *Store) finishJob(id string, status model.JobStatus, lastError string, runAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	job.Status = status
+	job.LastError = lastError
+	job.RunAt = runAt
+	job.LockedAt = nil
+	job.UpdatedAt = time.Now()
+
+	return nil
+}
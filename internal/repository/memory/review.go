@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateReview(ctx context.Context, review *model.Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertReview(review)
+}
+
+func (s *Store) CreateReviewWithJob(ctx context.Context, review *model.Review, job *model.ReviewJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.insertReview(review); err != nil {
+		return err
+	}
+
+	job.ReviewID = review.ID
+	return s.insertJob(job)
+}
+
+func (s *Store) UpdateReview(ctx context.Context, review *model.Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateReview(review, timestamp())
+	return nil
+}
+
+func (s *Store) GetReview(ctx context.Context, id string) (*model.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	review, ok := s.reviews[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	copied := *review
+	return &copied, nil
+}
+
+func (s *Store) GetReviews(ctx context.Context, opts model.ReviewListOptions) (*model.ReviewPage, error) {
+	var after interface{}
+	if opts.Cursor != nil {
+		value, err := opts.Cursor.SortValue()
+		if err != nil {
+			return nil, err
+		}
+		after = value
+	}
+
+	s.mu.Lock()
+	var reviews []*model.Review
+	for _, review := range s.reviews {
+		if !matches(review, opts.Filter) {
+			continue
+		}
+		if opts.Cursor != nil && !follows(review, opts, after) {
+			continue
+		}
+		copied := *review
+		reviews = append(reviews, &copied)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(reviews, func(i, j int) bool {
+		c := compareReviews(reviews[i], reviews[j], opts.Sort)
+		if opts.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	if len(reviews) > opts.Limit+1 {
+		reviews = reviews[:opts.Limit+1]
+	}
+
+	return model.NewReviewPage(reviews, opts), nil
+}
+
+func (s *Store) UpdateReviewWithTransition(ctx context.Context, review *model.Review, transition *model.StatusTransition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateReview(review, timestamp())
+	s.insertTransition(review, transition)
+	return nil
+}
+
+func (s *Store) UpdateReviewIfUnmodified(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.reviews[review.ID]
+	if !ok || !stored.UpdatedAt.Equal(version) {
+		return repository.ErrConflict
+	}
+
+	s.updateReview(review, timestamp())
+	if transition != nil {
+		s.insertTransition(review, transition)
+	}
+	return nil
+}
+
+func (s *Store) GetStatusHistory(ctx context.Context, reviewID string) ([]*model.StatusTransition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var history []*model.StatusTransition
+	for _, transition := range s.history[reviewID] {
+		copied := *transition
+		history = append(history, &copied)
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.Before(history[j].CreatedAt)
+	})
+
+	return history, nil
+}
+
+func (s *Store) insertReview(review *model.Review) error {
+	if review.ID == "" {
+		review.ID = uuid.New().String()
+	}
+	if _, ok := s.reviews[review.ID]; ok {
+		return fmt.Errorf("review %s already exists", review.ID)
+	}
+
+	now := timestamp()
+	review.CreatedAt = now
+	review.UpdatedAt = now
+
+	stored := *review
+	stored.Issues, stored.Metrics = nil, nil
+	s.reviews[review.ID] = &stored
+	return nil
+}
+
+// updateReview writes the fields the SQL stores update. Like them, it does
+// nothing when the review does not exist.
+func (s *Store) updateReview(review *model.Review, updatedAt time.Time) {
+	review.UpdatedAt = updatedAt
+
+	stored, ok := s.reviews[review.ID]
+	if !ok {
+		return
+	}
+	stored.Status = review.Status
+	stored.Title = review.Title
+	stored.Description = review.Description
+	stored.Feedback = review.Feedback
+	stored.CodeQuality = review.CodeQuality
+	stored.Performance = review.Performance
+	stored.BestPractices = review.BestPractices
+	stored.FilesTruncated = review.FilesTruncated
+	stored.UpdatedAt = updatedAt
+}
+
+func (s *Store) insertTransition(review *model.Review, transition *model.StatusTransition) {
+	if transition.ID == "" {
+		transition.ID = uuid.New().String()
+	}
+	transition.ReviewID = review.ID
+	transition.CreatedAt = review.UpdatedAt
+
+	copied := *transition
+	s.history[review.ID] = append(s.history[review.ID], &copied)
+}
+
+// matches reports whether review passes every filter that is set
+func matches(review *model.Review, f model.ReviewFilter) bool {
+	switch {
+	case f.Host != "" && review.Host != f.Host,
+		f.RepoOwner != "" && review.RepoOwner != f.RepoOwner,
+		f.RepoName != "" && review.RepoName != f.RepoName,
+		f.PRNumber != 0 && review.PRNumber != f.PRNumber,
+		f.Status != "" && review.Status != f.Status,
+		f.CommitHash != "" && review.CommitHash != f.CommitHash,
+		!inRange(review.CodeQuality, f.CodeQuality),
+		!inRange(review.Performance, f.Performance),
+		!inRange(review.BestPractices, f.BestPractices),
+		f.CreatedAfter != nil && review.CreatedAt.Before(*f.CreatedAfter),
+		f.CreatedBefore != nil && !review.CreatedAt.Before(*f.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+func inRange(score float64, bounds model.ScoreRange) bool {
+	return (bounds.Min == nil || score >= *bounds.Min) && (bounds.Max == nil || score <= *bounds.Max)
+}
+
+// follows reports whether review comes after the cursor position
+func follows(review *model.Review, opts model.ReviewListOptions, after interface{}) bool {
+	c := compareValues(sortValue(review, opts.Sort), after)
+	if c == 0 {
+		c = strings.Compare(review.ID, opts.Cursor.ID)
+	}
+	if opts.Descending {
+		return c < 0
+	}
+	return c > 0
+}
+
+// compareReviews orders reviews by the sort field, then by ID
+func compareReviews(a, b *model.Review, by model.ReviewSort) int {
+	if c := compareValues(sortValue(a, by), sortValue(b, by)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// sortValue returns the review's sort field typed like
+// model.ReviewCursor.SortValue
+func sortValue(review *model.Review, by model.ReviewSort) interface{} {
+	switch by {
+	case model.SortUpdatedAt:
+		return review.UpdatedAt
+	case model.SortPRNumber:
+		return review.PRNumber
+	case model.SortCodeQuality:
+		return review.CodeQuality
+	case model.SortPerformance:
+		return review.Performance
+	case model.SortBestPractices:
+		return review.BestPractices
+	default:
+		return review.CreatedAt
+	}
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case int:
+		return compareOrdered(a, b.(int))
+	case float64:
+		return compareOrdered(a, b.(float64))
+	}
+	return 0
+}
+
+func compareOrdered[T int | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+// Package memory keeps reviews, jobs and webhook deliveries in process
+// memory. It is meant for tests and for running the bot locally; everything
+// is lost when the process exits.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+)
+
+// Store implements every repository interface on top of maps. It copies
+// records on the way in and out, so callers never share memory with it.
+type Store struct {
+	mu         sync.Mutex
+	reviews    map[string]*model.Review
+	history    map[string][]*model.StatusTransition
+	issues     map[string][]model.ReviewIssue
+	metrics    map[string][]model.ReviewMetric
+	jobs       map[string]*model.ReviewJob
+	deliveries map[string]*model.WebhookDelivery
+}
+
+var (
+	_ repository.ReviewStore   = (*Store)(nil)
+	_ repository.JobStore      = (*Store)(nil)
+	_ repository.DeliveryStore = (*Store)(nil)
+)
+
+func New() *Store {
+	return &Store{
+		reviews:    make(map[string]*model.Review),
+		history:    make(map[string][]*model.StatusTransition),
+		issues:     make(map[string][]model.ReviewIssue),
+		metrics:    make(map[string][]model.ReviewMetric),
+		jobs:       make(map[string]*model.ReviewJob),
+		deliveries: make(map[string]*model.WebhookDelivery),
+	}
+}
+
+// timestamp matches the microsecond precision of the SQL stores, so review
+// versions behave the same whichever store is used
+func timestamp() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
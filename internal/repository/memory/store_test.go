@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"git-gud-bot/internal/repository/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		store := New()
+		return storetest.Stores{Reviews: store, Jobs: store, Deliveries: store}
+	})
+}
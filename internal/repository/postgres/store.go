@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"git-gud-bot/internal/repository/sqlstore"
+)
+
+// dialect keeps times as given, since Postgres stores them with their zone,
+// and skips jobs another worker has locked
+var dialect = sqlstore.Dialect{
+	Time:      func(t time.Time) time.Time { return t },
+	ClaimLock: "FOR UPDATE SKIP LOCKED",
+}
+
+// NewReviewRepository creates a review store on a Postgres database
+func NewReviewRepository(db *sql.DB) *sqlstore.ReviewRepository {
+	return sqlstore.NewReviewRepository(db, dialect)
+}
+
+// NewJobRepository creates a job store on a Postgres database
+func NewJobRepository(db *sql.DB) *sqlstore.JobRepository {
+	return sqlstore.NewJobRepository(db, dialect)
+}
+
+// NewDeliveryRepository creates a webhook delivery store on a Postgres database
+func NewDeliveryRepository(db *sql.DB) *sqlstore.DeliveryRepository {
+	return sqlstore.NewDeliveryRepository(db, dialect)
+}
@@ -0,0 +1,46 @@
+// Package sqlite stores reviews, jobs and webhook deliveries in a SQLite
+// database file, using a pure Go driver so no C toolchain or server is
+// needed. It shares its stores with the postgres package through sqlstore
+// and is meant for running the bot locally.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"git-gud-bot/internal/migrate"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens (creating it if needed) the database at path and brings its
+// schema up to date with the SQLite translations of internal/migrate. Use
+// ":memory:" for a throwaway database.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	params := url.Values{
+		"_pragma":      {"foreign_keys(1)", "busy_timeout(5000)"},
+		"_time_format": {"sqlite"},
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite allows a single writer, and every connection to ":memory:" would
+	// see a database of its own
+	db.SetMaxOpenConns(1)
+
+	migrator, err := migrate.NewSQLite(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	return db, nil
+}
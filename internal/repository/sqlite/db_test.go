@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"git-gud-bot/internal/migrate"
+	"git-gud-bot/internal/repository/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db, err := Open(context.Background(), filepath.Join(t.TempDir(), "gitgud.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return storetest.Stores{
+			Reviews:    NewReviewRepository(db),
+			Jobs:       NewJobRepository(db),
+			Deliveries: NewDeliveryRepository(db),
+		}
+	})
+}
+
+func TestOpenInMemory(t *testing.T) {
+	db, err := Open(context.Background(), ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrate.NewSQLite(db)
+	require.NoError(t, err)
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "migration %d", status.Version)
+	}
+}
@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"git-gud-bot/internal/repository/sqlstore"
+)
+
+// dialect stores times in UTC, since the driver writes them as text and
+// compares them as strings. SQLite runs one write at a time, so claiming a
+// job needs no lock.
+var dialect = sqlstore.Dialect{
+	Time: func(t time.Time) time.Time { return t.UTC() },
+}
+
+// NewReviewRepository creates a review store on a SQLite database
+func NewReviewRepository(db *sql.DB) *sqlstore.ReviewRepository {
+	return sqlstore.NewReviewRepository(db, dialect)
+}
+
+// NewJobRepository creates a job store on a SQLite database
+func NewJobRepository(db *sql.DB) *sqlstore.JobRepository {
+	return sqlstore.NewJobRepository(db, dialect)
+}
+
+// NewDeliveryRepository creates a webhook delivery store on a SQLite database
+func NewDeliveryRepository(db *sql.DB) *sqlstore.DeliveryRepository {
+	return sqlstore.NewDeliveryRepository(db, dialect)
+}
@@ -0,0 +1,164 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"git-gud-bot/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// SaveAnalysis saves the analyzed review together with its issues and
+// metrics, replacing those of an earlier attempt, in a single transaction.
+// The status transition is recorded too when it is not nil. Nothing is saved
+// and repository.ErrConflict is returned if the review's updated_at no longer
+// equals version.
+func (r *ReviewRepository) SaveAnalysis(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition, issues []model.ReviewIssue, metrics []model.ReviewMetric) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.updateReviewIfUnmodified(ctx, tx, review, version); err != nil {
+		return err
+	}
+
+	if transition != nil {
+		transition.ReviewID = review.ID
+		transition.CreatedAt = review.UpdatedAt
+		if err := r.insertTransition(ctx, tx, transition); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_issues WHERE review_id = $1`, review.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_metrics WHERE review_id = $1`, review.ID); err != nil {
+		return err
+	}
+
+	for i := range issues {
+		issues[i].ReviewID = review.ID
+		issues[i].CreatedAt = review.UpdatedAt
+		if err := r.insertIssue(ctx, tx, &issues[i]); err != nil {
+			return err
+		}
+	}
+	for i := range metrics {
+		metrics[i].ReviewID = review.ID
+		metrics[i].CreatedAt = review.UpdatedAt
+		if err := r.insertMetric(ctx, tx, &metrics[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ReviewRepository) GetIssues(ctx context.Context, reviewID string) ([]model.ReviewIssue, error) {
+	query := `
+		SELECT id, review_id, rule, file, line, type, category, severity,
+			   description, created_at
+		FROM review_issues
+		WHERE review_id = $1
+		ORDER BY file, line, rule
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issues := []model.ReviewIssue{}
+	for rows.Next() {
+		var issue model.ReviewIssue
+		err := rows.Scan(
+			&issue.ID, &issue.ReviewID, &issue.Rule, &issue.File, &issue.Line,
+			&issue.Type, &issue.Category, &issue.Severity, &issue.Description,
+			&issue.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
+
+func (r *ReviewRepository) GetMetrics(ctx context.Context, reviewID string) ([]model.ReviewMetric, error) {
+	query := `
+		SELECT id, review_id, file, name, function, line, value, description,
+			   created_at
+		FROM review_metrics
+		WHERE review_id = $1
+		ORDER BY file, line, name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []model.ReviewMetric{}
+	for rows.Next() {
+		var metric model.ReviewMetric
+		err := rows.Scan(
+			&metric.ID, &metric.ReviewID, &metric.File, &metric.Name,
+			&metric.Function, &metric.Line, &metric.Value, &metric.Description,
+			&metric.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, rows.Err()
+}
+
+func (s store) insertIssue(ctx context.Context, db execer, issue *model.ReviewIssue) error {
+	query := `
+		INSERT INTO review_issues (
+			id, review_id, rule, file, line, type, category, severity,
+			description, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	if issue.ID == "" {
+		issue.ID = uuid.New().String()
+	}
+
+	_, err := db.ExecContext(ctx, query,
+		issue.ID, issue.ReviewID, issue.Rule, issue.File, issue.Line,
+		issue.Type, issue.Category, issue.Severity, issue.Description,
+		issue.CreatedAt,
+	)
+
+	return err
+}
+
+func (s store) insertMetric(ctx context.Context, db execer, metric *model.ReviewMetric) error {
+	query := `
+		INSERT INTO review_metrics (
+			id, review_id, file, name, function, line, value, description,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	if metric.ID == "" {
+		metric.ID = uuid.New().String()
+	}
+
+	_, err := db.ExecContext(ctx, query,
+		metric.ID, metric.ReviewID, metric.File, metric.Name, metric.Function,
+		metric.Line, metric.Value, metric.Description, metric.CreatedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,153 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+)
+
+type DeliveryRepository struct {
+	store
+}
+
+var _ repository.DeliveryStore = (*DeliveryRepository)(nil)
+
+func NewDeliveryRepository(db *sql.DB, dialect Dialect) *DeliveryRepository {
+	return &DeliveryRepository{
+		store: store{db: db, dialect: dialect},
+	}
+}
+
+// CreateDelivery stores a new delivery and reports whether it was inserted.
+// A false result means a delivery with the same ID already exists.
+func (r *DeliveryRepository) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) (bool, error) {
+	query := `
+		INSERT INTO webhook_deliveries (
+			id, host, event, payload, status, error, attempts, review_id,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	now := r.timestamp()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	result, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.Host, delivery.Event, []byte(delivery.Payload), delivery.Status,
+		delivery.Error, delivery.Attempts, delivery.ReviewID,
+		delivery.CreatedAt, delivery.UpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted == 1, nil
+}
+
+func (r *DeliveryRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, error = $3, attempts = $4, review_id = $5, updated_at = $6
+		WHERE id = $1
+	`
+
+	delivery.UpdatedAt = r.timestamp()
+
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.Status, delivery.Error, delivery.Attempts,
+		delivery.ReviewID, delivery.UpdatedAt,
+	)
+
+	return err
+}
+
+// ClaimDelivery marks a failed or stale delivery as received again. Only one
+// of several concurrent callers sees a true result.
+func (r *DeliveryRepository) ClaimDelivery(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'received', updated_at = $2
+		WHERE id = $1
+		  AND (status = 'failed' OR (status = 'received' AND updated_at < $3))
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, r.timestamp(), r.dialect.Time(staleBefore))
+	if err != nil {
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return claimed == 1, nil
+}
+
+func (r *DeliveryRepository) GetDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	query := `
+		SELECT id, host, event, payload, status, error, attempts, review_id,
+			   created_at, updated_at
+		FROM webhook_deliveries
+		WHERE id = $1
+	`
+
+	delivery := &model.WebhookDelivery{}
+	var payload []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&delivery.ID, &delivery.Host, &delivery.Event, &payload, &delivery.Status,
+		&delivery.Error, &delivery.Attempts, &delivery.ReviewID,
+		&delivery.CreatedAt, &delivery.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+
+	return delivery, nil
+}
+
+// GetDeliveries lists deliveries with the given status, newest first. Payloads
+// are omitted to keep listings small.
+func (r *DeliveryRepository) GetDeliveries(ctx context.Context, status model.DeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	query := `
+		SELECT id, host, event, status, error, attempts, review_id,
+			   created_at, updated_at
+		FROM webhook_deliveries
+		WHERE status = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		delivery := &model.WebhookDelivery{}
+		err := rows.Scan(
+			&delivery.ID, &delivery.Host, &delivery.Event, &delivery.Status,
+			&delivery.Error, &delivery.Attempts, &delivery.ReviewID,
+			&delivery.CreatedAt, &delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,125 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type JobRepository struct {
+	store
+}
+
+var _ repository.JobStore = (*JobRepository)(nil)
+
+func NewJobRepository(db *sql.DB, dialect Dialect) *JobRepository {
+	return &JobRepository{
+		store: store{db: db, dialect: dialect},
+	}
+}
+
+func (s store) insertJob(ctx context.Context, db execer, job *model.ReviewJob) error {
+	query := `
+		INSERT INTO review_jobs (
+			id, review_id, status, attempts, last_error, run_at,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+
+	now := s.timestamp()
+	if job.Status == "" {
+		job.Status = model.JobQueued
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	job.RunAt = s.dialect.Time(job.RunAt)
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	_, err := db.ExecContext(ctx, query,
+		job.ID, job.ReviewID, job.Status, job.Attempts, job.LastError,
+		job.RunAt, job.CreatedAt, job.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *JobRepository) EnqueueJob(ctx context.Context, job *model.ReviewJob) error {
+	return r.insertJob(ctx, r.db, job)
+}
+
+// ClaimJob marks the next runnable job as running for this worker and
+// increments its attempt count. The dialect's claim lock keeps concurrent
+// workers from claiming the same job. Jobs left running longer than lease by
+// a crashed worker are claimed again. It returns nil when no job is ready.
+func (r *JobRepository) ClaimJob(ctx context.Context, lease time.Duration) (*model.ReviewJob, error) {
+	query := fmt.Sprintf(`
+		UPDATE review_jobs
+		SET status = $1, attempts = attempts + 1, locked_at = $2, updated_at = $2
+		WHERE id = (
+			SELECT id FROM review_jobs
+			WHERE (status = $3 AND run_at <= $2)
+			   OR (status = $1 AND locked_at < $4)
+			ORDER BY run_at
+			LIMIT 1
+			%s
+		)
+		RETURNING id, review_id, status, attempts, last_error, run_at,
+				  locked_at, created_at, updated_at
+	`, r.dialect.ClaimLock)
+
+	now := r.timestamp()
+	job := &model.ReviewJob{}
+	err := r.db.QueryRowContext(ctx, query,
+		model.JobRunning, now, model.JobQueued, now.Add(-lease),
+	).Scan(
+		&job.ID, &job.ReviewID, &job.Status, &job.Attempts, &job.LastError,
+		&job.RunAt, &job.LockedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *JobRepository) CompleteJob(ctx context.Context, id string) error {
+	return r.finishJob(ctx, id, model.JobCompleted, "", r.timestamp())
+}
+
+// RetryJob puts a job back in the queue to run again at runAt
+func (r *JobRepository) RetryJob(ctx context.Context, id string, runAt time.Time, lastError string) error {
+	return r.finishJob(ctx, id, model.JobQueued, lastError, runAt)
+}
+
+func (r *JobRepository) FailJob(ctx context.Context, id string, lastError string) error {
+	return r.finishJob(ctx, id, model.JobFailed, lastError, r.timestamp())
+}
+
+func (r *JobRepository) finishJob(ctx context.Context, id string, status model.JobStatus, lastError string, runAt time.Time) error {
+	query := `
+		UPDATE review_jobs
+		SET status = $2, last_error = $3, run_at = $4, locked_at = NULL, updated_at = $5
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, status, lastError, r.dialect.Time(runAt), r.timestamp())
+
+	return err
+}
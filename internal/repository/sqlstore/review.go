@@ -0,0 +1,259 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type ReviewRepository struct {
+	store
+}
+
+var _ repository.ReviewStore = (*ReviewRepository)(nil)
+
+func NewReviewRepository(db *sql.DB, dialect Dialect) *ReviewRepository {
+	return &ReviewRepository{
+		store: store{db: db, dialect: dialect},
+	}
+}
+
+func (r *ReviewRepository) CreateReview(ctx context.Context, review *model.Review) error {
+	return r.insertReview(ctx, r.db, review)
+}
+
+// CreateReviewWithJob stores a review together with the job that will
+// analyze it, so a review is never persisted without queued work.
+func (r *ReviewRepository) CreateReviewWithJob(ctx context.Context, review *model.Review, job *model.ReviewJob) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.insertReview(ctx, tx, review); err != nil {
+		return err
+	}
+
+	job.ReviewID = review.ID
+	if err := r.insertJob(ctx, tx, job); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ReviewRepository) UpdateReview(ctx context.Context, review *model.Review) error {
+	return r.updateReview(ctx, r.db, review)
+}
+
+func (r *ReviewRepository) GetReview(ctx context.Context, id string) (*model.Review, error) {
+	query := `
+		SELECT id, host, pr_number, repo_owner, repo_name, status, title,
+			   description, feedback, commit_hash, code_quality,
+			   performance, best_practices, files_truncated, created_at,
+			   updated_at
+		FROM reviews
+		WHERE id = $1
+	`
+
+	review := &model.Review{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&review.ID, &review.Host, &review.PRNumber, &review.RepoOwner, &review.RepoName,
+		&review.Status, &review.Title, &review.Description, &review.Feedback,
+		&review.CommitHash, &review.CodeQuality, &review.Performance,
+		&review.BestPractices, &review.FilesTruncated, &review.CreatedAt,
+		&review.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetReviews lists one page of reviews matching opts, which must have been
+// validated
+func (r *ReviewRepository) GetReviews(ctx context.Context, opts model.ReviewListOptions) (*model.ReviewPage, error) {
+	where, args, err := r.reviewFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	column := string(opts.Sort)
+	direction := "ASC"
+	if opts.Descending {
+		direction = "DESC"
+	}
+
+	args = append(args, opts.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT id, host, pr_number, repo_owner, repo_name, status, title,
+			   description, feedback, commit_hash, code_quality,
+			   performance, best_practices, files_truncated, created_at,
+			   updated_at
+		FROM reviews
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT $%d
+	`, where, column, direction, direction, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*model.Review
+	for rows.Next() {
+		review := &model.Review{}
+		err := rows.Scan(
+			&review.ID, &review.Host, &review.PRNumber, &review.RepoOwner, &review.RepoName,
+			&review.Status, &review.Title, &review.Description, &review.Feedback,
+			&review.CommitHash, &review.CodeQuality, &review.Performance,
+			&review.BestPractices, &review.FilesTruncated, &review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return model.NewReviewPage(reviews, opts), nil
+}
+
+// reviewFilter builds the WHERE clause for a listing, including the keyset
+// condition that continues after the cursor
+func (s store) reviewFilter(opts model.ReviewListOptions) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	f := opts.Filter
+	if f.Host != "" {
+		add("host = $%d", f.Host)
+	}
+	if f.RepoOwner != "" {
+		add("repo_owner = $%d", f.RepoOwner)
+	}
+	if f.RepoName != "" {
+		add("repo_name = $%d", f.RepoName)
+	}
+	if f.PRNumber != 0 {
+		add("pr_number = $%d", f.PRNumber)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.CommitHash != "" {
+		add("commit_hash = $%d", f.CommitHash)
+	}
+
+	scores := []struct {
+		column string
+		bounds model.ScoreRange
+	}{
+		{"code_quality", f.CodeQuality},
+		{"performance", f.Performance},
+		{"best_practices", f.BestPractices},
+	}
+	for _, score := range scores {
+		if score.bounds.Min != nil {
+			add(score.column+" >= $%d", *score.bounds.Min)
+		}
+		if score.bounds.Max != nil {
+			add(score.column+" <= $%d", *score.bounds.Max)
+		}
+	}
+
+	if f.CreatedAfter != nil {
+		add("created_at >= $%d", s.dialect.Time(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < $%d", s.dialect.Time(*f.CreatedBefore))
+	}
+
+	if opts.Cursor != nil {
+		value, err := opts.Cursor.SortValue()
+		if err != nil {
+			return "", nil, err
+		}
+		if t, ok := value.(time.Time); ok {
+			value = s.dialect.Time(t)
+		}
+		comparison := ">"
+		if opts.Descending {
+			comparison = "<"
+		}
+		args = append(args, value, opts.Cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)",
+			opts.Sort, comparison, len(args)-1, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func (s store) insertReview(ctx context.Context, db execer, review *model.Review) error {
+	query := `
+		INSERT INTO reviews (
+			id, host, pr_number, repo_owner, repo_name, status, title,
+			description, feedback, commit_hash, code_quality,
+			performance, best_practices, files_truncated, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	if review.ID == "" {
+		review.ID = uuid.New().String()
+	}
+
+	now := s.timestamp()
+	review.CreatedAt = now
+	review.UpdatedAt = now
+
+	_, err := db.ExecContext(ctx, query,
+		review.ID, review.Host, review.PRNumber, review.RepoOwner, review.RepoName,
+		review.Status, review.Title, review.Description, review.Feedback,
+		review.CommitHash, review.CodeQuality, review.Performance,
+		review.BestPractices, review.FilesTruncated, review.CreatedAt, review.UpdatedAt,
+	)
+
+	return err
+}
+
+func (s store) updateReview(ctx context.Context, db execer, review *model.Review) error {
+	query := `
+		UPDATE reviews
+		SET status = $2, title = $3, description = $4, feedback = $5,
+			code_quality = $6, performance = $7, best_practices = $8,
+			files_truncated = $9, updated_at = $10
+		WHERE id = $1
+	`
+
+	review.UpdatedAt = s.timestamp()
+
+	_, err := db.ExecContext(ctx, query,
+		review.ID, review.Status, review.Title, review.Description,
+		review.Feedback, review.CodeQuality, review.Performance,
+		review.BestPractices, review.FilesTruncated, review.UpdatedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,146 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// UpdateReviewWithTransition saves the review and records its status change
+// in review_status_history in a single transaction
+func (r *ReviewRepository) UpdateReviewWithTransition(ctx context.Context, review *model.Review, transition *model.StatusTransition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.updateReview(ctx, tx, review); err != nil {
+		return err
+	}
+
+	transition.ReviewID = review.ID
+	transition.CreatedAt = review.UpdatedAt
+	if err := r.insertTransition(ctx, tx, transition); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateReviewIfUnmodified saves the review only if its stored updated_at
+// still equals version, returning repository.ErrConflict otherwise. The
+// transition is recorded in the same transaction when it is not nil.
+func (r *ReviewRepository) UpdateReviewIfUnmodified(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.updateReviewIfUnmodified(ctx, tx, review, version); err != nil {
+		return err
+	}
+
+	if transition != nil {
+		transition.ReviewID = review.ID
+		transition.CreatedAt = review.UpdatedAt
+		if err := r.insertTransition(ctx, tx, transition); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// updateReviewIfUnmodified is updateReview guarded by the version the caller
+// read, returning repository.ErrConflict when the review changed since
+func (s store) updateReviewIfUnmodified(ctx context.Context, db execer, review *model.Review, version time.Time) error {
+	query := `
+		UPDATE reviews
+		SET status = $2, title = $3, description = $4, feedback = $5,
+			code_quality = $6, performance = $7, best_practices = $8,
+			files_truncated = $9, updated_at = $10
+		WHERE id = $1 AND updated_at = $11
+	`
+
+	updatedAt := s.timestamp()
+	result, err := db.ExecContext(ctx, query,
+		review.ID, review.Status, review.Title, review.Description,
+		review.Feedback, review.CodeQuality, review.Performance,
+		review.BestPractices, review.FilesTruncated, updatedAt,
+		s.dialect.Time(version),
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repository.ErrConflict
+	}
+	review.UpdatedAt = updatedAt
+
+	return nil
+}
+
+func (r *ReviewRepository) GetStatusHistory(ctx context.Context, reviewID string) ([]*model.StatusTransition, error) {
+	query := `
+		SELECT id, review_id, from_status, to_status, actor, reason, created_at
+		FROM review_status_history
+		WHERE review_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*model.StatusTransition
+	for rows.Next() {
+		transition := &model.StatusTransition{}
+		err := rows.Scan(
+			&transition.ID, &transition.ReviewID, &transition.FromStatus,
+			&transition.ToStatus, &transition.Actor, &transition.Reason,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, transition)
+	}
+
+	return history, rows.Err()
+}
+
+func (s store) insertTransition(ctx context.Context, db execer, transition *model.StatusTransition) error {
+	query := `
+		INSERT INTO review_status_history (
+			id, review_id, from_status, to_status, actor, reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	if transition.ID == "" {
+		transition.ID = uuid.New().String()
+	}
+	if transition.CreatedAt.IsZero() {
+		transition.CreatedAt = s.timestamp()
+	}
+
+	_, err := db.ExecContext(ctx, query,
+		transition.ID, transition.ReviewID, transition.FromStatus,
+		transition.ToStatus, transition.Actor, transition.Reason,
+		transition.CreatedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,39 @@
+// Package sqlstore implements the review, job and delivery stores on
+// database/sql for databases that take $N placeholders. The postgres and
+// sqlite packages open their databases and supply a Dialect describing how
+// they differ.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Dialect is what differs between the databases the stores run on
+type Dialect struct {
+	// Time converts a time before it is stored or compared with stored times
+	Time func(time.Time) time.Time
+	// ClaimLock follows the query that picks the next job, so concurrent
+	// workers skip a job another one is claiming. It is empty for databases
+	// that run one write at a time.
+	ClaimLock string
+}
+
+// store is the database and dialect shared by the repositories
+type store struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// timestamp returns the current time at the microsecond precision both
+// databases keep, so a review's UpdatedAt reads back exactly as it was
+// written and works as its version
+func (s store) timestamp() time.Time {
+	return s.dialect.Time(time.Now().Truncate(time.Microsecond))
+}
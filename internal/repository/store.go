@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"git-gud-bot/internal/model"
+)
+
+// ReviewStore persists reviews along with their status history and analysis
+// results. Lookups of a review that does not exist return sql.ErrNoRows.
+type ReviewStore interface {
+	CreateReview(ctx context.Context, review *model.Review) error
+	// CreateReviewWithJob stores a review together with the job that will
+	// analyze it, so a review is never persisted without queued work
+	CreateReviewWithJob(ctx context.Context, review *model.Review, job *model.ReviewJob) error
+	UpdateReview(ctx context.Context, review *model.Review) error
+	GetReview(ctx context.Context, id string) (*model.Review, error)
+	// GetReviews lists one page of reviews matching opts, which must have
+	// been validated
+	GetReviews(ctx context.Context, opts model.ReviewListOptions) (*model.ReviewPage, error)
+
+	// UpdateReviewWithTransition saves the review and records its status
+	// change atomically
+	UpdateReviewWithTransition(ctx context.Context, review *model.Review, transition *model.StatusTransition) error
+	// UpdateReviewIfUnmodified saves the review only if its stored UpdatedAt
+	// still equals version, returning ErrConflict otherwise. The transition is
+	// recorded atomically with it when it is not nil.
+	UpdateReviewIfUnmodified(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition) error
+	GetStatusHistory(ctx context.Context, reviewID string) ([]*model.StatusTransition, error)
+
+	// SaveAnalysis saves the analyzed review together with its issues and
+	// metrics, replacing those of an earlier attempt, atomically. The
+	// transition is recorded too when it is not nil. Like
+	// UpdateReviewIfUnmodified, it returns ErrConflict without saving
+	// anything when the review changed after version.
+	SaveAnalysis(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition, issues []model.ReviewIssue, metrics []model.ReviewMetric) error
+	GetIssues(ctx context.Context, reviewID string) ([]model.ReviewIssue, error)
+	GetMetrics(ctx context.Context, reviewID string) ([]model.ReviewMetric, error)
+}
+
+// JobStore is the queue review workers take their jobs from
+type JobStore interface {
+	EnqueueJob(ctx context.Context, job *model.ReviewJob) error
+	// ClaimJob marks the next runnable job as running and increments its
+	// attempt count. Jobs left running longer than lease are claimed again.
+	// It returns nil when no job is ready.
+	ClaimJob(ctx context.Context, lease time.Duration) (*model.ReviewJob, error)
+	CompleteJob(ctx context.Context, id string) error
+	// RetryJob puts a job back in the queue to run again at runAt
+	RetryJob(ctx context.Context, id string, runAt time.Time, lastError string) error
+	FailJob(ctx context.Context, id string, lastError string) error
+}
+
+// DeliveryStore records GitHub webhook deliveries. Lookups of a delivery that
+// does not exist return sql.ErrNoRows.
+type DeliveryStore interface {
+	// CreateDelivery stores a new delivery and reports whether it was
+	// inserted. A false result means a delivery with the same ID exists.
+	CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) (bool, error)
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// ClaimDelivery atomically marks a failed delivery, or one left received
+	// since before staleBefore, as received again and reports whether it did.
+	// Only the caller that claims a delivery may process it.
+	ClaimDelivery(ctx context.Context, id string, staleBefore time.Time) (bool, error)
+	GetDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	// GetDeliveries lists deliveries with the given status, newest first,
+	// without their payloads
+	GetDeliveries(ctx context.Context, status model.DeliveryStatus, limit int) ([]*model.WebhookDelivery, error)
+}
@@ -0,0 +1,321 @@
+// Package storetest checks that an implementation of the repository
+// interfaces behaves like the Postgres one the service was written against.
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Stores is a set of stores sharing the same underlying data
+type Stores struct {
+	Reviews    repository.ReviewStore
+	Jobs       repository.JobStore
+	Deliveries repository.DeliveryStore
+}
+
+// Run runs the conformance tests, calling open for an empty set of stores
+// in each of them
+func Run(t *testing.T, open func(t *testing.T) Stores) {
+	t.Run("Reviews", func(t *testing.T) { testReviews(t, open(t)) })
+	t.Run("OptimisticUpdate", func(t *testing.T) { testOptimisticUpdate(t, open(t)) })
+	t.Run("ListReviews", func(t *testing.T) { testListReviews(t, open(t)) })
+	t.Run("Analysis", func(t *testing.T) { testAnalysis(t, open(t)) })
+	t.Run("Jobs", func(t *testing.T) { testJobs(t, open(t)) })
+	t.Run("Deliveries", func(t *testing.T) { testDeliveries(t, open(t)) })
+}
+
+func newReview(pr int) *model.Review {
+	return &model.Review{
+		Host:       "github.com",
+		PRNumber:   pr,
+		RepoOwner:  "octo",
+		RepoName:   "hello",
+		Status:     model.StatusPending,
+		CommitHash: fmt.Sprintf("sha%d", pr),
+	}
+}
+
+func testReviews(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	review := newReview(1)
+	require.NoError(t, s.Reviews.CreateReview(ctx, review))
+	assert.NotEmpty(t, review.ID)
+	assert.False(t, review.CreatedAt.IsZero())
+
+	review.Status = model.StatusApproved
+	review.CodeQuality = 91.5
+	review.FilesTruncated = true
+	require.NoError(t, s.Reviews.UpdateReview(ctx, review))
+
+	stored, err := s.Reviews.GetReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusApproved, stored.Status)
+	assert.Equal(t, 91.5, stored.CodeQuality)
+	assert.True(t, stored.FilesTruncated)
+	assert.Equal(t, "sha1", stored.CommitHash)
+	assert.True(t, stored.UpdatedAt.Equal(review.UpdatedAt))
+
+	failed := newReview(2)
+	require.NoError(t, s.Reviews.CreateReview(ctx, failed))
+	failed.Status = model.StatusFailed
+	require.NoError(t, s.Reviews.UpdateReview(ctx, failed))
+
+	_, err = s.Reviews.GetReview(ctx, "missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testOptimisticUpdate(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	review := newReview(1)
+	require.NoError(t, s.Reviews.CreateReview(ctx, review))
+	version := review.UpdatedAt
+
+	// Let the clock move past the version
+	time.Sleep(time.Millisecond)
+
+	review.Status = model.StatusNeedWork
+	transition := &model.StatusTransition{FromStatus: model.StatusPending, ToStatus: model.StatusNeedWork, Actor: "alice"}
+	require.NoError(t, s.Reviews.UpdateReviewIfUnmodified(ctx, review, version, transition))
+	assert.True(t, review.UpdatedAt.After(version))
+
+	review.Status = model.StatusApproved
+	err := s.Reviews.UpdateReviewIfUnmodified(ctx, review, version, nil)
+	assert.ErrorIs(t, err, repository.ErrConflict)
+
+	stored, err := s.Reviews.GetReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusNeedWork, stored.Status)
+
+	history, err := s.Reviews.GetStatusHistory(ctx, review.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "alice", history[0].Actor)
+	assert.Equal(t, model.StatusNeedWork, history[0].ToStatus)
+}
+
+func testListReviews(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	for pr := 1; pr <= 5; pr++ {
+		review := newReview(pr)
+		if pr == 3 {
+			review.RepoName = "other"
+		}
+		require.NoError(t, s.Reviews.CreateReview(ctx, review))
+		review.CodeQuality = float64(pr * 10)
+		require.NoError(t, s.Reviews.UpdateReview(ctx, review))
+	}
+
+	opts := model.ReviewListOptions{
+		Filter: model.ReviewFilter{RepoName: "hello"},
+		Sort:   model.SortPRNumber,
+		Limit:  2,
+	}
+	require.NoError(t, opts.Validate())
+
+	var prs []int
+	for pages := 0; pages < 5; pages++ {
+		page, err := s.Reviews.GetReviews(ctx, opts)
+		require.NoError(t, err)
+		for _, review := range page.Reviews {
+			prs = append(prs, review.PRNumber)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor, err = model.DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int{1, 2, 4, 5}, prs)
+
+	minQuality := 25.0
+	opts = model.ReviewListOptions{
+		Filter:     model.ReviewFilter{CodeQuality: model.ScoreRange{Min: &minQuality}},
+		Sort:       model.SortCodeQuality,
+		Descending: true,
+	}
+	require.NoError(t, opts.Validate())
+	page, err := s.Reviews.GetReviews(ctx, opts)
+	require.NoError(t, err)
+	require.Equal(t, 3, page.Count)
+	assert.Equal(t, 5, page.Reviews[0].PRNumber)
+	assert.Equal(t, 3, page.Reviews[2].PRNumber)
+	assert.Empty(t, page.NextCursor)
+
+	opts = model.ReviewListOptions{Limit: 3}
+	require.NoError(t, opts.Validate())
+	page, err = s.Reviews.GetReviews(ctx, opts)
+	require.NoError(t, err)
+	opts.Cursor, err = model.DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	page, err = s.Reviews.GetReviews(ctx, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, page.Count)
+	assert.Equal(t, 2, page.Reviews[0].PRNumber)
+	assert.Equal(t, 1, page.Reviews[1].PRNumber)
+}
+
+func testAnalysis(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	review := newReview(1)
+	require.NoError(t, s.Reviews.CreateReview(ctx, review))
+
+	issues := []model.ReviewIssue{
+		{Rule: "go/ignored-error", File: "b.go", Line: 3, Type: "bug", Category: "errors", Severity: "high", Description: "ignored"},
+		{Rule: "go/naming", File: "a.go", Line: 7, Type: "style", Category: "naming", Severity: "low", Description: "name"},
+	}
+	metrics := []model.ReviewMetric{{File: "a.go", Name: "complexity", Function: "main", Line: 1, Value: 4}}
+	version := review.UpdatedAt
+	time.Sleep(time.Millisecond)
+	review.FilesTruncated = true
+	require.NoError(t, s.Reviews.SaveAnalysis(ctx, review, version, nil, issues, metrics))
+
+	analyzed, err := s.Reviews.GetReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.True(t, analyzed.FilesTruncated)
+
+	// An analysis of a stale read is rejected as a whole
+	err = s.Reviews.SaveAnalysis(ctx, review, version, nil, nil, nil)
+	assert.ErrorIs(t, err, repository.ErrConflict)
+
+	stored, err := s.Reviews.GetIssues(ctx, review.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "a.go", stored[0].File)
+	assert.Equal(t, review.ID, stored[0].ReviewID)
+
+	// A retried analysis replaces what the earlier attempt stored
+	review.Status = model.StatusApproved
+	transition := &model.StatusTransition{FromStatus: model.StatusPending, ToStatus: model.StatusApproved, Actor: "bot"}
+	require.NoError(t, s.Reviews.SaveAnalysis(ctx, review, review.UpdatedAt, transition, issues[:1], nil))
+
+	stored, err = s.Reviews.GetIssues(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+	storedMetrics, err := s.Reviews.GetMetrics(ctx, review.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, storedMetrics)
+	assert.Empty(t, storedMetrics)
+
+	history, err := s.Reviews.GetStatusHistory(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func testJobs(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	review := newReview(1)
+	job := &model.ReviewJob{}
+	require.NoError(t, s.Reviews.CreateReviewWithJob(ctx, review, job))
+	assert.Equal(t, review.ID, job.ReviewID)
+
+	claimed, err := s.Jobs.ClaimJob(ctx, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, job.ID, claimed.ID)
+	assert.Equal(t, model.JobRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+
+	claimed, err = s.Jobs.ClaimJob(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "a running job is only claimed again after its lease")
+
+	require.NoError(t, s.Jobs.RetryJob(ctx, job.ID, time.Now().Add(time.Hour), "flaky"))
+	claimed, err = s.Jobs.ClaimJob(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "a retried job waits for its run time")
+
+	require.NoError(t, s.Jobs.RetryJob(ctx, job.ID, time.Now().Add(-time.Second), "flaky"))
+	claimed, err = s.Jobs.ClaimJob(ctx, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, 2, claimed.Attempts)
+	assert.Equal(t, "flaky", claimed.LastError)
+
+	// A lease that already expired lets another worker take the job over
+	claimed, err = s.Jobs.ClaimJob(ctx, -time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, 3, claimed.Attempts)
+
+	require.NoError(t, s.Jobs.CompleteJob(ctx, job.ID))
+	claimed, err = s.Jobs.ClaimJob(ctx, -time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, claimed)
+}
+
+func testDeliveries(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	delivery := &model.WebhookDelivery{
+		ID:      "d-1",
+		Host:    "github.com",
+		Event:   "pull_request",
+		Payload: []byte(`{"action":"opened"}`),
+		Status:  model.DeliveryReceived,
+	}
+	inserted, err := s.Deliveries.CreateDelivery(ctx, delivery)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+
+	inserted, err = s.Deliveries.CreateDelivery(ctx, &model.WebhookDelivery{ID: "d-1", Event: "ping", Payload: []byte(`{}`), Status: model.DeliveryReceived})
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	claimed, err := s.Deliveries.ClaimDelivery(ctx, "d-1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, claimed, "a delivery in flight is not claimable")
+
+	claimed, err = s.Deliveries.ClaimDelivery(ctx, "d-1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, claimed, "a stale received delivery is claimable")
+
+	delivery.Status = model.DeliveryFailed
+	delivery.Error = "boom"
+	delivery.Attempts = 1
+	require.NoError(t, s.Deliveries.UpdateDelivery(ctx, delivery))
+
+	stored, err := s.Deliveries.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, "pull_request", stored.Event)
+	assert.JSONEq(t, `{"action":"opened"}`, string(stored.Payload))
+	assert.Equal(t, "boom", stored.Error)
+
+	failed, err := s.Deliveries.GetDeliveries(ctx, model.DeliveryFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Empty(t, failed[0].Payload)
+
+	claimed, err = s.Deliveries.ClaimDelivery(ctx, "d-1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = s.Deliveries.ClaimDelivery(ctx, "d-1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, claimed, "a delivery is claimed only once")
+
+	stored, err = s.Deliveries.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryReceived, stored.Status)
+
+	claimed, err = s.Deliveries.ClaimDelivery(ctx, "missing", time.Now())
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	_, err = s.Deliveries.GetDelivery(ctx, "missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
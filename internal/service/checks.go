@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repoconfig"
+	"git-gud-bot/pkg/analyzer"
+	"git-gud-bot/pkg/github"
+)
+
+// CheckRunName is shown in the PR merge box and used by branch protection
+const CheckRunName = "Git Gud Bot"
+
+// startCheckRun puts an in-progress check run on the reviewed commit. A
+// retried job takes over the run an earlier attempt created, found by its
+// external ID, instead of adding another. It returns nil if there is no run,
+// e.g. because the token lacks the checks permission; the review carries on
+// without it.
+func (s *ReviewService) startCheckRun(ctx context.Context, client *github.Client, review *model.Review) *github.CheckRun {
+	now := time.Now()
+	started := &github.CheckRun{
+		Name:       CheckRunName,
+		HeadSHA:    review.CommitHash,
+		Status:     github.CheckStatusInProgress,
+		StartedAt:  &now,
+		ExternalID: review.ID,
+		Output: &github.CheckRunOutput{
+			Title:   "Analyzing",
+			Summary: "Git Gud Bot is reviewing this commit.",
+		},
+	}
+
+	run, err := s.findCheckRun(ctx, client, review)
+	if err != nil {
+		log.Printf("review %s: failed to list check runs: %v", review.ID, err)
+		return nil
+	}
+
+	if run != nil {
+		run, err = client.UpdateCheckRun(ctx, review.RepoOwner, review.RepoName, run.ID, started)
+	} else {
+		run, err = client.CreateCheckRun(ctx, review.RepoOwner, review.RepoName, started)
+	}
+	if err != nil {
+		log.Printf("review %s: failed to start check run: %v", review.ID, err)
+		return nil
+	}
+
+	return run
+}
+
+// findCheckRun returns the check run an attempt at the review created, or
+// nil if there is none
+func (s *ReviewService) findCheckRun(ctx context.Context, client *github.Client, review *model.Review) (*github.CheckRun, error) {
+	runs, err := client.ListCheckRuns(ctx, review.RepoOwner, review.RepoName, review.CommitHash, CheckRunName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range runs {
+		if runs[i].ExternalID == review.ID {
+			return &runs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// completeCheckRun concludes the check run from the review status and
+// attaches an annotation per issue, in batches GitHub will accept.
+// Annotations an earlier attempt already added to the run are not repeated.
+// A notice, if any, follows the summary.
+func (s *ReviewService) completeCheckRun(ctx context.Context, client *github.Client, review *model.Review, run *github.CheckRun, pr *github.PullRequest, analysis *analyzer.Analysis, reason, notice string, checks repoconfig.ChecksConfig) error {
+	if run == nil {
+		return nil
+	}
+
+	var annotations []github.CheckAnnotation
+	for _, issue := range analysis.Issues {
+		if issue.File == "" || issue.Line <= 0 {
+			continue
+		}
+		annotations = append(annotations, github.CheckAnnotation{
+			Path:            issue.File,
+			StartLine:       issue.Line,
+			EndLine:         issue.Line,
+			AnnotationLevel: annotationLevel(issue.Severity),
+			Title:           issue.Type,
+			Message:         issue.Description,
+		})
+	}
+
+	if run.Output != nil {
+		annotations = annotations[min(run.Output.AnnotationsCount, len(annotations)):]
+	}
+
+	summary := fmt.Sprintf("Review status **%s** because %s. Found %d issue(s).", review.Status, reason, len(analysis.Issues)) +
+		truncationNote(pr)
+	if notice != "" {
+		summary += "\n\n" + notice
+	}
+
+	output := func(batch []github.CheckAnnotation) *github.CheckRunOutput {
+		return &github.CheckRunOutput{
+			Title: fmt.Sprintf("%s: quality %.1f, performance %.1f, best practices %.1f",
+				review.Status, review.CodeQuality, review.Performance, review.BestPractices),
+			Summary:     summary,
+			Annotations: batch,
+		}
+	}
+
+	// Every batch but the last keeps the run in progress
+	for len(annotations) > github.MaxAnnotationsPerRequest {
+		batch := annotations[:github.MaxAnnotationsPerRequest]
+		annotations = annotations[github.MaxAnnotationsPerRequest:]
+		if _, err := client.UpdateCheckRun(ctx, review.RepoOwner, review.RepoName, run.ID, &github.CheckRun{
+			Output: output(batch),
+		}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	completed := &github.CheckRun{
+		Status:      github.CheckStatusCompleted,
+		Conclusion:  checkConclusion(review.Status, checks),
+		CompletedAt: &now,
+		Output:      output(annotations),
+	}
+	// GitHub links action_required runs to where the action is to be taken
+	if completed.Conclusion == github.CheckConclusionActionRequired {
+		completed.DetailsURL = pr.HTMLURL
+	}
+
+	_, err := client.UpdateCheckRun(ctx, review.RepoOwner, review.RepoName, run.ID, completed)
+	return err
+}
+
+// failCheckRun fails the check run of a review whose analysis has been given
+// up on, so it does not stay in progress forever. Attempts that will be
+// retried leave the run in progress instead, so a flaky GitHub or a deploy
+// doesn't turn the check red. A run that was already concluded is kept.
+func (s *ReviewService) failCheckRun(ctx context.Context, client *github.Client, review *model.Review, cause error) {
+	run, err := s.findCheckRun(ctx, client, review)
+	if err != nil {
+		log.Printf("review %s: failed to list check runs: %v", review.ID, err)
+		return
+	}
+	if run == nil || run.Status == github.CheckStatusCompleted {
+		return
+	}
+
+	now := time.Now()
+	_, err = client.UpdateCheckRun(ctx, review.RepoOwner, review.RepoName, run.ID, &github.CheckRun{
+		Status:      github.CheckStatusCompleted,
+		Conclusion:  github.CheckConclusionFailure,
+		CompletedAt: &now,
+		Output: &github.CheckRunOutput{
+			Title:   "Analysis failed",
+			Summary: "Git Gud Bot could not finish reviewing this commit: " + cause.Error(),
+		},
+	})
+	if err != nil {
+		log.Printf("review %s: failed to close check run: %v", review.ID, err)
+	}
+}
+
+// checkConclusion maps a review status to a check run conclusion. Only an
+// approval passes; reviews that need work conclude as the repository chose.
+func checkConclusion(status model.ReviewStatus, checks repoconfig.ChecksConfig) string {
+	switch status {
+	case model.StatusApproved:
+		return github.CheckConclusionSuccess
+	case model.StatusNeedWork:
+		return checks.NeedsWork
+	default:
+		return github.CheckConclusionFailure
+	}
+}
+
+func annotationLevel(severity string) string {
+	switch severity {
+	case analyzer.SeverityHigh:
+		return github.AnnotationFailure
+	case analyzer.SeverityMedium:
+		return github.AnnotationWarning
+	default:
+		return github.AnnotationNotice
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repoconfig"
+	"git-gud-bot/internal/repository/memory"
+	"git-gud-bot/pkg/github"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConclusion(t *testing.T) {
+	checks := repoconfig.Default().Checks
+
+	assert.Equal(t, github.CheckConclusionSuccess, checkConclusion(model.StatusApproved, checks))
+	assert.Equal(t, github.CheckConclusionFailure, checkConclusion(model.StatusNeedWork, checks))
+	assert.Equal(t, github.CheckConclusionFailure, checkConclusion(model.StatusRejected, checks))
+	assert.Equal(t, github.CheckConclusionFailure, checkConclusion(model.StatusFailed, checks))
+
+	checks.NeedsWork = github.CheckConclusionActionRequired
+	assert.Equal(t, github.CheckConclusionActionRequired, checkConclusion(model.StatusNeedWork, checks))
+}
+
+func TestFailedAttemptKeepsCheckRunInProgress(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestService(t, memory.New())
+
+	// The pull request doesn't exist, so the analysis can't finish
+	review, err := s.CreateReview(ctx, &model.ReviewRequest{PRNumber: 404, RepoOwner: "octo", RepoName: "app", CommitHash: "gone"})
+	require.NoError(t, err)
+	cause := s.ProcessReview(ctx, review.ID)
+	assert.Error(t, cause)
+
+	// The attempt may be retried, so the check stays pending
+	runs := server.CheckRuns()
+	require.Len(t, runs, 1)
+	assert.Equal(t, github.CheckStatusInProgress, runs[0].Status)
+
+	// Once the job is given up on, the check fails
+	require.NoError(t, s.MarkReviewFailed(ctx, review.ID, cause))
+	runs = server.CheckRuns()
+	require.Len(t, runs, 1)
+	assert.Equal(t, github.CheckStatusCompleted, runs[0].Status)
+	assert.Equal(t, github.CheckConclusionFailure, runs[0].Conclusion)
+}
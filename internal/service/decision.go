@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repoconfig"
+	"git-gud-bot/pkg/analyzer"
+)
+
+// DecisionPolicy maps an analysis to a review status. The lowest of the three
+// scores is compared with the thresholds: below NeedsWork is rejected, at or
+// above Approve is approved and anything between needs work. Any issue with a
+// blocking severity prevents approval regardless of the scores.
+type DecisionPolicy struct {
+	Approve            float64
+	NeedsWork          float64
+	BlockingSeverities map[string]bool
+}
+
+func NewDecisionPolicy(thresholds repoconfig.Thresholds) DecisionPolicy {
+	return DecisionPolicy{
+		Approve:   thresholds.Approve,
+		NeedsWork: thresholds.NeedsWork,
+		BlockingSeverities: map[string]bool{
+			analyzer.SeverityHigh: true,
+		},
+	}
+}
+
+// Decide returns the status for the analysis and a human readable reason
+func (p DecisionPolicy) Decide(analysis *analyzer.Analysis) (model.ReviewStatus, string) {
+	lowest := math.Min(analysis.CodeQuality, math.Min(analysis.Performance, analysis.BestPractices))
+
+	if lowest < p.NeedsWork {
+		return model.StatusRejected, fmt.Sprintf("lowest score %.1f is below the needs_work threshold of %.0f", lowest, p.NeedsWork)
+	}
+
+	blocking := 0
+	for _, issue := range analysis.Issues {
+		if p.BlockingSeverities[issue.Severity] {
+			blocking++
+		}
+	}
+	if blocking > 0 {
+		return model.StatusNeedWork, fmt.Sprintf("%d blocking issue(s) must be fixed", blocking)
+	}
+
+	if lowest >= p.Approve {
+		return model.StatusApproved, fmt.Sprintf("all scores are at or above the approve threshold of %.0f", p.Approve)
+	}
+
+	return model.StatusNeedWork, fmt.Sprintf("lowest score %.1f is below the approve threshold of %.0f", lowest, p.Approve)
+}
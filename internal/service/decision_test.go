@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repoconfig"
+	"git-gud-bot/pkg/analyzer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecisionPolicy(t *testing.T) {
+	policy := NewDecisionPolicy(repoconfig.Default().Thresholds)
+
+	cases := []struct {
+		name     string
+		analysis analyzer.Analysis
+		want     model.ReviewStatus
+	}{
+		{
+			name:     "clean",
+			analysis: analyzer.Analysis{CodeQuality: 95, Performance: 100, BestPractices: 88},
+			want:     model.StatusApproved,
+		},
+		{
+			name:     "middling",
+			analysis: analyzer.Analysis{CodeQuality: 95, Performance: 70, BestPractices: 88},
+			want:     model.StatusNeedWork,
+		},
+		{
+			name:     "poor",
+			analysis: analyzer.Analysis{CodeQuality: 95, Performance: 100, BestPractices: 40},
+			want:     model.StatusRejected,
+		},
+		{
+			name: "blocking issue",
+			analysis: analyzer.Analysis{
+				CodeQuality: 95, Performance: 100, BestPractices: 90,
+				Issues: []analyzer.Issue{{Severity: analyzer.SeverityHigh}},
+			},
+			want: model.StatusNeedWork,
+		},
+	}
+
+	for _, c := range cases {
+		status, reason := policy.Decide(&c.analysis)
+		assert.Equal(t, c.want, status, c.name)
+		assert.NotEmpty(t, reason, c.name)
+	}
+}
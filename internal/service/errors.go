@@ -0,0 +1,93 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"git-gud-bot/pkg/github"
+)
+
+// Kind classifies a service error; the API answers each kind with its own
+// HTTP status
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalidRequest
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUpstream
+	KindRateLimited
+)
+
+// Error is a failure the service reports to its callers. Code is a stable,
+// machine readable identifier and Message is safe to show to API clients.
+// Err is the underlying cause, if any, which is kept for logs and errors.As
+// but never shown to clients.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any error with the same code, so errors.Is works with the
+// sentinel values below even when a cause has been attached
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// withCause returns a copy of the error wrapping err
+func (e *Error) withCause(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+var (
+	ErrReviewNotFound   = &Error{Kind: KindNotFound, Code: "review_not_found", Message: "review not found"}
+	ErrDeliveryNotFound = &Error{Kind: KindNotFound, Code: "delivery_not_found", Message: "delivery not found"}
+
+	ErrUpstream    = &Error{Kind: KindUpstream, Code: "github_unavailable", Message: "GitHub request failed"}
+	ErrRateLimited = &Error{Kind: KindRateLimited, Code: "github_rate_limited", Message: "GitHub rate limit exceeded; try again later"}
+)
+
+// notFound turns the sql.ErrNoRows of a store lookup into notFoundErr
+func notFound(err error, notFoundErr *Error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFoundErr
+	}
+	return err
+}
+
+// upstreamError classifies a failed GitHub call as ErrRateLimited or
+// ErrUpstream. The GitHub error stays reachable through errors.As, so callers
+// can still tell whether it is worth retrying.
+func upstreamError(err error) error {
+	var rateErr *github.RateLimitError
+	var apiErr *github.APIError
+	if errors.As(err, &rateErr) || (errors.As(err, &apiErr) && apiErr.RateLimited) {
+		return ErrRateLimited.withCause(err)
+	}
+	return ErrUpstream.withCause(err)
+}
+
+// isGitHubError reports whether err came from the GitHub API
+func isGitHubError(err error) bool {
+	var rateErr *github.RateLimitError
+	var apiErr *github.APIError
+	return errors.As(err, &rateErr) || errors.As(err, &apiErr)
+}
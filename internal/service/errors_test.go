@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository/memory"
+	"git-gud-bot/pkg/github"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("failed to fetch: %w", ErrUpstream.withCause(errors.New("connection reset")))
+	assert.ErrorIs(t, err, ErrUpstream)
+	assert.NotErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, "failed to fetch: GitHub request failed: connection reset", err.Error())
+
+	assert.ErrorIs(t, notFound(fmt.Errorf("scan: %w", sql.ErrNoRows), ErrReviewNotFound), ErrReviewNotFound)
+	other := errors.New("connection refused")
+	assert.Equal(t, other, notFound(other, ErrReviewNotFound))
+}
+
+func TestUpstreamError(t *testing.T) {
+	assert.ErrorIs(t, upstreamError(&github.RateLimitError{Reset: time.Now()}), ErrRateLimited)
+	assert.ErrorIs(t, upstreamError(&github.APIError{StatusCode: 403, RateLimited: true}), ErrRateLimited)
+
+	err := upstreamError(&github.APIError{StatusCode: 502})
+	assert.ErrorIs(t, err, ErrUpstream)
+
+	// The GitHub error stays reachable for retry decisions
+	var apiErr *github.APIError
+	assert.ErrorAs(t, err, &apiErr)
+}
+
+func TestReviewNotFound(t *testing.T) {
+	ctx := context.Background()
+	s := NewReviewService(memory.New(), github.NewHosts(), nil)
+
+	_, err := s.GetReview(ctx, "missing", model.ReviewInclude{})
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+
+	_, err = s.GetStatusHistory(ctx, "missing")
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+
+	_, err = s.UpdateReview(ctx, "missing", time.Now(), &model.ReviewUpdateRequest{}, "alice")
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+}
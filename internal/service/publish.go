@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/pkg/analyzer"
+	"git-gud-bot/pkg/github"
+)
+
+// publishReview posts the analysis to the pull request as one batched review:
+// an inline comment per issue that falls on a line of the diff plus a summary
+// body with the scores and every issue that could not be anchored. With
+// inline comments turned off every issue is listed in the summary, and a
+// notice, if any, follows the verdict.
+//
+// The summary carries a marker with the review ID. If a pull request review
+// with the marker exists, an earlier attempt posted it even if it never saw
+// the response, and nothing is posted again.
+func (s *ReviewService) publishReview(ctx context.Context, client *github.Client, review *model.Review, pr *github.PullRequest, analysis *analyzer.Analysis, reason, notice string, inline bool) error {
+	marker := reviewMarker(review)
+	published, err := client.ListPullRequestReviews(ctx, review.RepoOwner, review.RepoName, review.PRNumber)
+	if err != nil {
+		return err
+	}
+	for _, existing := range published {
+		if strings.Contains(existing.Body, marker) {
+			return nil
+		}
+	}
+
+	patches := make(map[string]*github.Patch, len(pr.Files))
+	for _, file := range pr.Files {
+		patch, err := github.ParsePatch(file.Patch)
+		if err != nil {
+			log.Printf("review %s: skipping inline comments for %s: %v", review.ID, file.Name, err)
+			continue
+		}
+		patches[file.Name] = patch
+	}
+
+	var comments []github.DraftReviewComment
+	var unanchored []analyzer.Issue
+
+	for _, issue := range analysis.Issues {
+		position, ok := 0, false
+		if patch := patches[issue.File]; inline && patch != nil && issue.Line > 0 {
+			position, ok = patch.Position(issue.Line)
+		}
+		if !ok {
+			unanchored = append(unanchored, issue)
+			continue
+		}
+		comments = append(comments, github.DraftReviewComment{
+			Path:     issue.File,
+			Position: position,
+			Body:     formatIssue(issue),
+		})
+	}
+
+	return client.CreatePullRequestReview(ctx, review.RepoOwner, review.RepoName, review.PRNumber, &github.PullRequestReview{
+		CommitID: review.CommitHash,
+		Body:     formatSummary(review, pr, analysis, reason, notice, unanchored) + "\n" + marker + "\n",
+		Event:    github.ReviewEventComment,
+		Comments: comments,
+	})
+}
+
+// reviewMarker identifies the pull request review posted for a review
+func reviewMarker(review *model.Review) string {
+	return "<!-- git-gud-bot review " + review.ID + " -->"
+}
+
+func formatSummary(review *model.Review, pr *github.PullRequest, analysis *analyzer.Analysis, reason, notice string, unanchored []analyzer.Issue) string {
+	var b strings.Builder
+
+	b.WriteString("## Git Gud Bot review\n\n")
+	fmt.Fprintf(&b, "**Verdict: %s** (%s)\n\n", review.Status, reason)
+	if notice != "" {
+		b.WriteString(notice + "\n\n")
+	}
+	b.WriteString("| Score | Value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Code quality | %.1f |\n", review.CodeQuality)
+	fmt.Fprintf(&b, "| Performance | %.1f |\n", review.Performance)
+	fmt.Fprintf(&b, "| Best practices | %.1f |\n", review.BestPractices)
+
+	switch len(analysis.Issues) {
+	case 0:
+		b.WriteString("\nNo issues found. Nice work!\n")
+	case 1:
+		b.WriteString("\nFound 1 issue.\n")
+	default:
+		fmt.Fprintf(&b, "\nFound %d issues.\n", len(analysis.Issues))
+	}
+
+	if note := truncationNote(pr); note != "" {
+		b.WriteString("\n>" + note + "\n")
+	}
+
+	if deductions := formatDeductions(analysis.Breakdown); deductions != "" {
+		b.WriteString("\n<details><summary>How the scores were calculated</summary>\n\n")
+		b.WriteString(deductions)
+		b.WriteString("\n</details>\n")
+	}
+
+	if len(unanchored) > 0 {
+		b.WriteString("\n### Other findings\n\n")
+		for _, issue := range unanchored {
+			location := issue.File
+			if location == "" {
+				location = "general"
+			} else if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+			}
+			fmt.Fprintf(&b, "- `%s`: %s\n", location, formatIssue(issue))
+		}
+	}
+
+	return b.String()
+}
+
+// truncationNote warns that part of a very large pull request went unreviewed
+func truncationNote(pr *github.PullRequest) string {
+	if !pr.FilesTruncated {
+		return ""
+	}
+	return fmt.Sprintf(" :warning: GitHub lists at most %d files per pull request, so only %d of the %d changed files were reviewed.",
+		github.MaxPullRequestFiles, len(pr.Files), pr.ChangedFiles)
+}
+
+func formatIssue(issue analyzer.Issue) string {
+	return fmt.Sprintf("**%s** (%s): %s", issue.Type, issue.Severity, issue.Description)
+}
+
+func formatDeductions(breakdown []analyzer.ScoreBreakdown) string {
+	var b strings.Builder
+	for _, category := range breakdown {
+		for _, deduction := range category.Deductions {
+			fmt.Fprintf(&b, "- %s: %+.1f (%s)\n", category.Category, -deduction.Points, deduction.Reason)
+		}
+	}
+	return b.String()
+}
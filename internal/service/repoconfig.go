@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repoconfig"
+	"git-gud-bot/pkg/github"
+)
+
+// loadRepoConfig reads .gitgud.yml from the PR's base branch. A missing file
+// means defaults. An invalid one means defaults too, plus a notice explaining
+// why, which goes into the check run and review the commit gets anyway
+// rather than into a comment of its own on every run.
+func (s *ReviewService) loadRepoConfig(ctx context.Context, client *github.Client, review *model.Review, pr *github.PullRequest) (*repoconfig.Config, string, error) {
+	data, err := client.GetFileContents(ctx, review.RepoOwner, review.RepoName, repoconfig.FileName, pr.Base.Ref)
+	if err != nil {
+		var apiErr *github.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return repoconfig.Default(), "", nil
+		}
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", repoconfig.FileName, upstreamError(err))
+	}
+
+	cfg, err := repoconfig.Parse(data, s.analyzer.Registry().RuleNames())
+	if err != nil {
+		log.Printf("review %s: ignoring invalid %s: %v", review.ID, repoconfig.FileName, err)
+		notice := fmt.Sprintf("⚠️ Git Gud Bot could not use `%s` from `%s`, so this review uses the default settings.\n\n```\n%v\n```",
+			repoconfig.FileName, pr.Base.Ref, err)
+		return repoconfig.Default(), notice, nil
+	}
+
+	return cfg, "", nil
+}
@@ -2,70 +2,402 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"git-gud-bot/internal/model"
-	"git-gud-bot/internal/repository/postgres"
+	"git-gud-bot/internal/repository"
 	"git-gud-bot/pkg/analyzer"
 	"git-gud-bot/pkg/github"
 )
 
+var (
+	ErrConflict          = &Error{Kind: KindConflict, Code: "review_conflict", Message: "review was modified by someone else; reload and try again"}
+	ErrInvalidStatus     = &Error{Kind: KindValidation, Code: "invalid_status", Message: "invalid review status"}
+	ErrInvalidTransition = &Error{Kind: KindValidation, Code: "invalid_transition", Message: "status transition not allowed"}
+
+	ErrJustificationRequired = &Error{Kind: KindValidation, Code: "justification_required", Message: "an override needs a justification"}
+	ErrInvalidQuery          = &Error{Kind: KindInvalidRequest, Code: "invalid_query", Message: "invalid review query"}
+
+	// ErrUnknownHost is github.ErrUnknownHost classified for callers; errors.Is
+	// matches either
+	ErrUnknownHost = &Error{Kind: KindValidation, Code: "unknown_host", Message: "no GitHub host configured with that name", Err: github.ErrUnknownHost}
+)
+
+// ReviewServicer is what the API needs from the review service
+type ReviewServicer interface {
+	CreateReview(ctx context.Context, req *model.ReviewRequest) (*model.Review, error)
+	GetReview(ctx context.Context, id string, include model.ReviewInclude) (*model.Review, error)
+	GetReviews(ctx context.Context, opts model.ReviewListOptions) (*model.ReviewPage, error)
+	UpdateReview(ctx context.Context, id string, version time.Time, req *model.ReviewUpdateRequest, actor string) (*model.Review, error)
+	OverrideReview(ctx context.Context, id string, version time.Time, req *model.ReviewOverrideRequest, actor string) (*model.Review, error)
+	GetStatusHistory(ctx context.Context, id string) ([]*model.StatusTransition, error)
+}
+
+var _ ReviewServicer = (*ReviewService)(nil)
+
 type ReviewService struct {
-	repo     *postgres.ReviewRepository
-	github   *github.Client
+	repo     repository.ReviewStore
+	hosts    *github.Hosts
 	analyzer *analyzer.CodeAnalyzer
 }
 
 func NewReviewService(
-	repo *postgres.ReviewRepository,
-	github *github.Client,
+	repo repository.ReviewStore,
+	hosts *github.Hosts,
 	analyzer *analyzer.CodeAnalyzer,
 ) *ReviewService {
 	return &ReviewService{
 		repo:     repo,
-		github:   github,
+		hosts:    hosts,
 		analyzer: analyzer,
 	}
 }
 
+// CreateReview stores a pending review and queues it for analysis
 func (s *ReviewService) CreateReview(ctx context.Context, req *model.ReviewRequest) (*model.Review, error) {
-	// Fetch PR details from GitHub
-	prDetails, err := s.github.GetPullRequest(ctx, req.RepoOwner, req.RepoName, req.PRNumber)
+	client, err := s.hostClient(req.Host)
 	if err != nil {
 		return nil, err
 	}
 
+	// Store the instance's hostname, not whichever alias the request used
+	review := &model.Review{
+		Host:       client.Endpoints().Host(),
+		PRNumber:   req.PRNumber,
+		RepoOwner:  req.RepoOwner,
+		RepoName:   req.RepoName,
+		Status:     model.StatusPending,
+		CommitHash: req.CommitHash,
+	}
+
+	if err := s.repo.CreateReviewWithJob(ctx, review, &model.ReviewJob{}); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// ProcessReview fetches the pull request for a queued review, analyzes it and
+// stores the resulting scores
+//
+// A failed attempt leaves the check run in progress for the next one;
+// MarkReviewFailed concludes it once the review is given up on.
+func (s *ReviewService) ProcessReview(ctx context.Context, id string) error {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load review: %w", notFound(err, ErrReviewNotFound))
+	}
+	// Saving is refused if someone changes the review while it is analyzed
+	version := review.UpdatedAt
+
+	// A status set by a person stands; the bot only adds its scores
+	decidedBy, err := s.statusActor(ctx, review.ID)
+	if err != nil {
+		return err
+	}
+
+	// Talk to the GitHub instance the repository lives on
+	client, err := s.hostClient(review.Host)
+	if err != nil {
+		return err
+	}
+
+	// Show the review as running in the PR merge box
+	checkRun := s.startCheckRun(ctx, client, review)
+
+	// Fetch PR details from GitHub
+	prDetails, err := client.GetPullRequest(ctx, review.RepoOwner, review.RepoName, review.PRNumber)
+	if err != nil {
+		return upstreamError(err)
+	}
+
+	// Load the repository's own settings
+	repoConfig, notice, err := s.loadRepoConfig(ctx, client, review, prDetails)
+	if err != nil {
+		return err
+	}
+
 	// Analyze code
-	analysis, err := s.analyzer.AnalyzeCode(ctx, prDetails)
+	analysis, err := s.analyzer.WithClient(client).AnalyzeCode(ctx, prDetails, repoConfig.AnalyzerOptions())
+	if isGitHubError(err) {
+		return upstreamError(err)
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Create review object
-	review := &model.Review{
-		PRNumber:      req.PRNumber,
-		RepoOwner:     req.RepoOwner,
-		RepoName:      req.RepoName,
-		Status:        model.StatusPending,
-		Title:         prDetails.Title,
-		Description:   prDetails.Description,
-		CommitHash:    req.CommitHash,
-		CodeQuality:   analysis.CodeQuality,
-		Performance:   analysis.Performance,
-		BestPractices: analysis.BestPractices,
-	}
-
-	// Save to database
-	if err := s.repo.CreateReview(ctx, review); err != nil {
-		return nil, err
+	review.Title = prDetails.Title
+	review.Description = prDetails.Description
+	review.CodeQuality = analysis.CodeQuality
+	review.Performance = analysis.Performance
+	review.BestPractices = analysis.BestPractices
+	review.FilesTruncated = prDetails.FilesTruncated
+
+	// Decide the outcome and record how the status changed
+	status, reason := NewDecisionPolicy(repoConfig.Thresholds).Decide(analysis)
+	var transition *model.StatusTransition
+	if decidedBy != model.ActorBot {
+		reason = fmt.Sprintf("%s set the status by hand", decidedBy)
+	} else if review.Status.CanTransitionTo(status) {
+		transition = &model.StatusTransition{
+			FromStatus: review.Status,
+			ToStatus:   status,
+			Actor:      model.ActorBot,
+			Reason:     reason,
+		}
+		review.Status = status
+	}
+
+	// Store the findings along with the review
+	issues, metrics := analysisRecords(analysis)
+	err = s.repo.SaveAnalysis(ctx, review, version, transition, issues, metrics)
+	if errors.Is(err, repository.ErrConflict) {
+		// Retry against the current review, which may carry a human decision
+		return fmt.Errorf("review changed during analysis: %w", ErrConflict)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Conclude the check run
+	if err := s.completeCheckRun(ctx, client, review, checkRun, prDetails, analysis, reason, notice, repoConfig.Checks); err != nil {
+		return fmt.Errorf("failed to complete check run: %w", upstreamError(err))
+	}
+
+	// Post the results back to the pull request
+	if err := s.publishReview(ctx, client, review, prDetails, analysis, reason, notice, repoConfig.Comments.Inline); err != nil {
+		return fmt.Errorf("failed to publish review: %w", upstreamError(err))
+	}
+
+	return nil
+}
+
+// hostClient returns the client for a GitHub host. A host nobody configured
+// is a validation error, which is never worth retrying.
+func (s *ReviewService) hostClient(host string) (*github.Client, error) {
+	client, err := s.hosts.Client(host)
+	if err != nil {
+		return nil, ErrUnknownHost.withCause(err)
+	}
+	return client, nil
+}
+
+// statusActor returns who last set the review's status, which is the bot
+// while nobody has changed it
+func (s *ReviewService) statusActor(ctx context.Context, id string) (string, error) {
+	history, err := s.repo.GetStatusHistory(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to load status history: %w", err)
+	}
+	if len(history) == 0 {
+		return model.ActorBot, nil
+	}
+	return history[len(history)-1].Actor, nil
+}
+
+// MarkReviewFailed records why a review could not be analyzed. A review
+// still waiting for analysis moves to failed; one that somebody already
+// decided keeps their status. Either way its check run is failed.
+func (s *ReviewService) MarkReviewFailed(ctx context.Context, id string, cause error) error {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load review: %w", notFound(err, ErrReviewNotFound))
+	}
+
+	version := review.UpdatedAt
+	review.Feedback = "Analysis failed: " + cause.Error()
+
+	var transition *model.StatusTransition
+	if review.Status == model.StatusPending {
+		transition = &model.StatusTransition{
+			FromStatus: review.Status,
+			ToStatus:   model.StatusFailed,
+			Actor:      model.ActorBot,
+			Reason:     cause.Error(),
+		}
+		review.Status = model.StatusFailed
+	}
+
+	if err := s.save(ctx, review, version, transition); err != nil {
+		return err
+	}
+
+	// A review on a host that is no longer configured has no run to close
+	if client, err := s.hosts.Client(review.Host); err == nil {
+		s.failCheckRun(ctx, client, review, cause)
+	}
+	return nil
+}
+
+// GetReview loads a review along with the related records include asks for
+func (s *ReviewService) GetReview(ctx context.Context, id string, include model.ReviewInclude) (*model.Review, error) {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrReviewNotFound)
+	}
+
+	if include.Issues {
+		if review.Issues, err = s.repo.GetIssues(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to load issues: %w", err)
+		}
+	}
+	if include.Metrics {
+		if review.Metrics, err = s.repo.GetMetrics(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to load metrics: %w", err)
+		}
 	}
 
 	return review, nil
 }
 
-func (s *ReviewService) GetReview(ctx context.Context, id string) (*model.Review, error) {
-	return s.repo.GetReview(ctx, id)
+// UpdateReview applies a partial update on behalf of actor. version must be
+// the UpdatedAt the caller last saw; a status change must be an allowed
+// transition.
+func (s *ReviewService) UpdateReview(ctx context.Context, id string, version time.Time, req *model.ReviewUpdateRequest, actor string) (*model.Review, error) {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrReviewNotFound)
+	}
+	if !review.UpdatedAt.Equal(version) {
+		return nil, ErrConflict
+	}
+
+	if req.Feedback != nil {
+		review.Feedback = *req.Feedback
+	}
+
+	var transition *model.StatusTransition
+	if req.Status != nil && *req.Status != review.Status {
+		reason := req.Reason
+		if reason == "" {
+			reason = "updated via API"
+		}
+		if transition, err = newTransition(review, *req.Status, actor, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	return review, s.save(ctx, review, version, transition)
 }
 
-func (s *ReviewService) GetReviews(ctx context.Context) ([]*model.Review, error) {
-	return s.repo.GetReviews(ctx)
+// OverrideReview replaces the bot's status with a human decision. Unlike
+// UpdateReview it may move between any two decided statuses, e.g. approve a
+// review the bot rejected. The justification is recorded in the status
+// history.
+func (s *ReviewService) OverrideReview(ctx context.Context, id string, version time.Time, req *model.ReviewOverrideRequest, actor string) (*model.Review, error) {
+	if strings.TrimSpace(req.Justification) == "" {
+		return nil, ErrJustificationRequired
+	}
+
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrReviewNotFound)
+	}
+	if !review.UpdatedAt.Equal(version) {
+		return nil, ErrConflict
+	}
+
+	if !req.Status.Decided() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, req.Status)
+	}
+	if req.Status == review.Status {
+		return nil, fmt.Errorf("%w: the review is already %s", ErrInvalidTransition, review.Status)
+	}
+
+	transition := &model.StatusTransition{
+		FromStatus: review.Status,
+		ToStatus:   req.Status,
+		Actor:      actor,
+		Reason:     "override: " + req.Justification,
+	}
+	review.Status = req.Status
+
+	return review, s.save(ctx, review, version, transition)
+}
+
+func (s *ReviewService) save(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition) error {
+	err := s.repo.UpdateReviewIfUnmodified(ctx, review, version, transition)
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrConflict
+	}
+	return err
+}
+
+// analysisRecords flattens an analysis into the rows stored with a review
+func analysisRecords(analysis *analyzer.Analysis) ([]model.ReviewIssue, []model.ReviewMetric) {
+	issues := make([]model.ReviewIssue, 0, len(analysis.Issues))
+	for _, issue := range analysis.Issues {
+		issues = append(issues, model.ReviewIssue{
+			Rule:        issue.Rule,
+			File:        issue.File,
+			Line:        issue.Line,
+			Type:        issue.Type,
+			Category:    issue.Category,
+			Severity:    issue.Severity,
+			Description: issue.Description,
+		})
+	}
+
+	files := make([]string, 0, len(analysis.Metrics))
+	for file := range analysis.Metrics {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var metrics []model.ReviewMetric
+	for _, file := range files {
+		for _, metric := range analysis.Metrics[file] {
+			metrics = append(metrics, model.ReviewMetric{
+				File:        file,
+				Name:        metric.Name,
+				Function:    metric.Function,
+				Line:        metric.Line,
+				Value:       metric.Value,
+				Description: metric.Description,
+			})
+		}
+	}
+
+	return issues, metrics
+}
+
+// newTransition validates a status change and applies it to the review
+func newTransition(review *model.Review, to model.ReviewStatus, actor, reason string) (*model.StatusTransition, error) {
+	if !to.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, to)
+	}
+	if !review.Status.CanTransitionTo(to) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, review.Status, to)
+	}
+
+	transition := &model.StatusTransition{
+		FromStatus: review.Status,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+	}
+	review.Status = to
+
+	return transition, nil
+}
+
+// GetStatusHistory lists a review's status changes, oldest first
+func (s *ReviewService) GetStatusHistory(ctx context.Context, id string) ([]*model.StatusTransition, error) {
+	if _, err := s.repo.GetReview(ctx, id); err != nil {
+		return nil, notFound(err, ErrReviewNotFound)
+	}
+	return s.repo.GetStatusHistory(ctx, id)
+}
+
+// GetReviews lists one page of reviews. Invalid options are reported as
+// ErrInvalidQuery.
+func (s *ReviewService) GetReviews(ctx context.Context, opts model.ReviewListOptions) (*model.ReviewPage, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	return s.repo.GetReviews(ctx, opts)
 }
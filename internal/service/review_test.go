@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+	"git-gud-bot/internal/repository/memory"
+	"git-gud-bot/pkg/analyzer"
+	"git-gud-bot/pkg/github"
+	"git-gud-bot/pkg/github/githubtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalysisRecords(t *testing.T) {
+	analysis := &analyzer.Analysis{
+		Issues: []analyzer.Issue{
+			{Rule: "go/ignored-error", File: "b.go", Line: 4, Type: "error-handling", Category: "best_practices", Severity: "medium", Description: "ignored"},
+		},
+		Metrics: map[string][]analyzer.Metric{
+			"b.go": {{Name: "cyclomatic_complexity", Function: "Run", Line: 3, Value: 12}},
+			"a.go": {{Name: "changed_lines", Value: 40}},
+		},
+	}
+
+	issues, metrics := analysisRecords(analysis)
+
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "go/ignored-error", issues[0].Rule)
+		assert.Equal(t, 4, issues[0].Line)
+		assert.Equal(t, "medium", issues[0].Severity)
+	}
+	if assert.Len(t, metrics, 2) {
+		// Files are stored in a stable order
+		assert.Equal(t, "a.go", metrics[0].File)
+		assert.Equal(t, "b.go", metrics[1].File)
+		assert.Equal(t, "Run", metrics[1].Function)
+		assert.Equal(t, 12.0, metrics[1].Value)
+	}
+}
+
+// newTestService returns a service backed by a memory store and a fake
+// GitHub server holding pull request octo/app#1
+func newTestService(t *testing.T, store repository.ReviewStore) (*ReviewService, *githubtest.Server) {
+	server := githubtest.NewServer()
+	t.Cleanup(server.Close)
+
+	repo := github.Repository{Name: "app", FullName: "octo/app", Owner: github.User{Login: "octo"}}
+	server.AddPullRequest("octo", "app", github.PullRequest{
+		Number: 1,
+		Title:  "Add greeting",
+		Head:   github.Branch{Ref: "feature", SHA: "head", Repo: repo},
+		Base:   github.Branch{Ref: "main", SHA: "base", Repo: repo},
+		Files: []github.File{
+			{Name: "main.go", Status: "added"},
+		},
+	})
+	server.AddFile("octo", "app", "head", "main.go", []byte("package main\n\nfunc save() error { return nil }\n\nfunc main() {\n\tsave()\n}\n"))
+
+	hosts := github.NewHosts()
+	hosts.Add(github.DefaultHost, server.Client())
+	return NewReviewService(store, hosts, analyzer.NewCodeAnalyzer(server.Client())), server
+}
+
+func createTestReview(t *testing.T, s *ReviewService) *model.Review {
+	review, err := s.CreateReview(context.Background(), &model.ReviewRequest{
+		PRNumber:   1,
+		RepoOwner:  "octo",
+		RepoName:   "app",
+		CommitHash: "head",
+	})
+	require.NoError(t, err)
+	return review
+}
+
+func TestProcessReviewRetryPublishesOnce(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestService(t, memory.New())
+	review := createTestReview(t, s)
+
+	// The second run stands in for a retry after the first one's results
+	// reached GitHub but the job still failed
+	require.NoError(t, s.ProcessReview(ctx, review.ID))
+	require.NoError(t, s.ProcessReview(ctx, review.ID))
+
+	assert.Len(t, server.Reviews(), 1)
+
+	runs := server.CheckRuns()
+	require.Len(t, runs, 1)
+	assert.Equal(t, github.CheckStatusCompleted, runs[0].Status)
+	assert.Len(t, runs[0].Output.Annotations, 1, "annotations are not repeated")
+}
+
+func TestProcessReviewReportsInvalidConfigOnce(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestService(t, memory.New())
+	server.AddFile("octo", "app", "main", ".gitgud.yml", []byte("thresholds: [not a map]\n"))
+	review := createTestReview(t, s)
+
+	require.NoError(t, s.ProcessReview(ctx, review.ID))
+	require.NoError(t, s.ProcessReview(ctx, review.ID))
+
+	assert.Empty(t, server.IssueComments())
+
+	reviews := server.Reviews()
+	require.Len(t, reviews, 1)
+	assert.Contains(t, reviews[0].Body, "could not use `.gitgud.yml` from `main`")
+
+	runs := server.CheckRuns()
+	require.Len(t, runs, 1)
+	assert.Contains(t, runs[0].Output.Summary, "could not use `.gitgud.yml`")
+}
+
+func TestProcessReviewKeepsHumanDecision(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, memory.New())
+	review := createTestReview(t, s)
+
+	_, err := s.OverrideReview(ctx, review.ID, review.UpdatedAt, &model.ReviewOverrideRequest{
+		Status:        model.StatusRejected,
+		Justification: "breaks the release branch",
+	}, "alice")
+	require.NoError(t, err)
+
+	require.NoError(t, s.ProcessReview(ctx, review.ID))
+
+	stored, err := s.GetReview(ctx, review.ID, model.ReviewInclude{})
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusRejected, stored.Status)
+	assert.NotZero(t, stored.CodeQuality)
+
+	history, err := s.GetStatusHistory(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestProcessReviewRecordsTruncatedFiles(t *testing.T) {
+	ctx := context.Background()
+	s, server := newTestService(t, memory.New())
+	repo := github.Repository{Name: "app", FullName: "octo/app", Owner: github.User{Login: "octo"}}
+	server.AddPullRequest("octo", "app", github.PullRequest{
+		Number:       1,
+		Title:        "Add greeting",
+		Head:         github.Branch{Ref: "feature", SHA: "head", Repo: repo},
+		Base:         github.Branch{Ref: "main", SHA: "base", Repo: repo},
+		Files:        []github.File{{Name: "main.go", Status: "added"}},
+		ChangedFiles: 2,
+	})
+	review := createTestReview(t, s)
+	assert.False(t, review.FilesTruncated)
+
+	require.NoError(t, s.ProcessReview(ctx, review.ID))
+
+	stored, err := s.GetReview(ctx, review.ID, model.ReviewInclude{})
+	require.NoError(t, err)
+	assert.True(t, stored.FilesTruncated)
+}
+
+// overridingStore lets a person override the review while it is analyzed
+type overridingStore struct {
+	*memory.Store
+}
+
+func (s overridingStore) SaveAnalysis(ctx context.Context, review *model.Review, version time.Time, transition *model.StatusTransition, issues []model.ReviewIssue, metrics []model.ReviewMetric) error {
+	human := *review
+	human.Status = model.StatusRejected
+	err := s.UpdateReviewIfUnmodified(ctx, &human, version, &model.StatusTransition{
+		FromStatus: review.Status, ToStatus: model.StatusRejected, Actor: "alice",
+	})
+	if err != nil {
+		return err
+	}
+	return s.Store.SaveAnalysis(ctx, review, version, transition, issues, metrics)
+}
+
+func TestProcessReviewConflict(t *testing.T) {
+	ctx := context.Background()
+	store := overridingStore{memory.New()}
+	s, _ := newTestService(t, store)
+	review := createTestReview(t, s)
+
+	err := s.ProcessReview(ctx, review.ID)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	stored, err := store.GetReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusRejected, stored.Status)
+}
+
+func TestOverrideReview(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	s, _ := newTestService(t, store)
+	review := createTestReview(t, s)
+
+	review.Status = model.StatusRejected
+	require.NoError(t, store.UpdateReviewWithTransition(ctx, review, &model.StatusTransition{
+		FromStatus: model.StatusPending, ToStatus: model.StatusRejected, Actor: model.ActorBot,
+	}))
+
+	// The bot's transitions don't allow this, but a person may
+	overridden, err := s.OverrideReview(ctx, review.ID, review.UpdatedAt, &model.ReviewOverrideRequest{
+		Status:        model.StatusApproved,
+		Justification: "false positive",
+	}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusApproved, overridden.Status)
+
+	_, err = s.OverrideReview(ctx, review.ID, overridden.UpdatedAt, &model.ReviewOverrideRequest{
+		Status:        model.StatusPending,
+		Justification: "start over",
+	}, "alice")
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+}
+
+func TestCreateReviewStoresCanonicalHost(t *testing.T) {
+	s, server := newTestService(t, memory.New())
+
+	// The request names the host by the alias it was registered under
+	review, err := s.CreateReview(context.Background(), &model.ReviewRequest{
+		Host:       github.DefaultHost,
+		PRNumber:   1,
+		RepoOwner:  "octo",
+		RepoName:   "app",
+		CommitHash: "head",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, server.Client().Endpoints().Host(), review.Host)
+
+	_, err = s.CreateReview(context.Background(), &model.ReviewRequest{Host: "gitlab.com", PRNumber: 1, RepoOwner: "octo", RepoName: "app", CommitHash: "head"})
+	assert.ErrorIs(t, err, ErrUnknownHost)
+}
+
+func TestProcessReviewUnknownHost(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	s, _ := newTestService(t, store)
+
+	review := &model.Review{Host: "ghe.example.com", PRNumber: 1, RepoOwner: "octo", RepoName: "app", Status: model.StatusPending, CommitHash: "head"}
+	require.NoError(t, store.CreateReview(ctx, review))
+
+	err := s.ProcessReview(ctx, review.ID)
+	assert.ErrorIs(t, err, ErrUnknownHost)
+	assert.ErrorIs(t, err, github.ErrUnknownHost)
+
+	var serr *Error
+	require.ErrorAs(t, err, &serr)
+	assert.Equal(t, KindValidation, serr.Kind)
+}
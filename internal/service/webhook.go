@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+	"git-gud-bot/pkg/github"
+)
+
+var (
+	ErrInvalidPayload    = &Error{Kind: KindInvalidRequest, Code: "invalid_payload", Message: "invalid webhook payload"}
+	ErrMissingDeliveryID = &Error{Kind: KindInvalidRequest, Code: "missing_delivery_id", Message: "missing delivery ID"}
+	ErrDuplicateDelivery = &Error{Kind: KindConflict, Code: "duplicate_delivery", Message: "delivery already received"}
+	ErrDeliveryNotFailed = &Error{Kind: KindConflict, Code: "delivery_not_failed", Message: "only failed or stalled deliveries can be replayed"}
+	ErrHostMismatch      = &Error{Kind: KindInvalidRequest, Code: "host_mismatch", Message: "delivery header names a different GitHub host than its payload"}
+)
+
+// staleDeliveryAge is how long a delivery may stay received before it is
+// assumed to have been abandoned, for example by a crash, and may be claimed
+// again
+const staleDeliveryAge = 10 * time.Minute
+
+type WebhookService struct {
+	reviews    *ReviewService
+	deliveries repository.DeliveryStore
+}
+
+func NewWebhookService(reviews *ReviewService, deliveries repository.DeliveryStore) *WebhookService {
+	return &WebhookService{
+		reviews:    reviews,
+		deliveries: deliveries,
+	}
+}
+
+// HandleDelivery records a verified webhook delivery and processes it once.
+// Redeliveries of a delivery that is in flight or already handled return
+// ErrDuplicateDelivery; redeliveries of a failed or stalled delivery are
+// reprocessed by whichever caller claims it first.
+//
+// host is the X-GitHub-Enterprise-Host header, or empty for github.com. The
+// header is not signed, so the delivery is routed by the host in its payload
+// and rejected with ErrHostMismatch when the two differ.
+func (s *WebhookService) HandleDelivery(ctx context.Context, id, host, event string, payload []byte) (*model.WebhookDelivery, error) {
+	if id == "" {
+		return nil, ErrMissingDeliveryID
+	}
+
+	host, err := deliveryHost(host, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &model.WebhookDelivery{
+		ID:      id,
+		Host:    host,
+		Event:   event,
+		Payload: payload,
+		Status:  model.DeliveryReceived,
+	}
+
+	inserted, err := s.deliveries.CreateDelivery(ctx, delivery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record delivery: %w", err)
+	}
+
+	if !inserted {
+		claimed, err := s.claim(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim delivery: %w", err)
+		}
+
+		existing, err := s.deliveries.GetDelivery(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load delivery: %w", err)
+		}
+		if !claimed {
+			return existing, ErrDuplicateDelivery
+		}
+		delivery = existing
+	}
+
+	return delivery, s.process(ctx, delivery)
+}
+
+// deliveryHost returns the host a delivery is routed to: the one its signed
+// payload names, which the header must agree with
+func deliveryHost(header string, payload []byte) (string, error) {
+	host, err := github.PayloadHost(payload)
+	if err != nil {
+		return "", ErrInvalidPayload.withCause(err)
+	}
+
+	header = github.NormalizeHost(header)
+	if host == "" {
+		return header, nil
+	}
+	if host != header {
+		return "", fmt.Errorf("%w: %q, payload %q", ErrHostMismatch, header, host)
+	}
+	return host, nil
+}
+
+// ReplayDelivery reprocesses a stored delivery that previously failed, or
+// that has been stuck in received for longer than staleDeliveryAge
+func (s *WebhookService) ReplayDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	claimed, err := s.claim(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+
+	delivery, err := s.deliveries.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrDeliveryNotFound)
+	}
+
+	if !claimed {
+		return delivery, ErrDeliveryNotFailed
+	}
+
+	return delivery, s.process(ctx, delivery)
+}
+
+// claim reserves a failed or stalled delivery for processing so that
+// concurrent replays and redeliveries handle it only once
+func (s *WebhookService) claim(ctx context.Context, id string) (bool, error) {
+	return s.deliveries.ClaimDelivery(ctx, id, time.Now().Add(-staleDeliveryAge))
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, status model.DeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	return s.deliveries.GetDeliveries(ctx, status, limit)
+}
+
+// HandleEvent processes a verified GitHub webhook event. It returns the
+// created review, or nil when the event does not require one.
+func (s *WebhookService) HandleEvent(ctx context.Context, host, event string, payload []byte) (*model.Review, error) {
+	switch event {
+	case github.EventPullRequest:
+		return s.handlePullRequest(ctx, host, payload)
+	default:
+		return nil, nil
+	}
+}
+
+func (s *WebhookService) process(ctx context.Context, delivery *model.WebhookDelivery) error {
+	delivery.Attempts++
+
+	review, processErr := s.HandleEvent(ctx, delivery.Host, delivery.Event, delivery.Payload)
+	switch {
+	case processErr != nil:
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = processErr.Error()
+	case review == nil:
+		delivery.Status = model.DeliveryIgnored
+		delivery.Error = ""
+	default:
+		delivery.Status = model.DeliveryProcessed
+		delivery.Error = ""
+		delivery.ReviewID = review.ID
+	}
+
+	if err := s.deliveries.UpdateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	return processErr
+}
+
+func (s *WebhookService) handlePullRequest(ctx context.Context, host string, payload []byte) (*model.Review, error) {
+	event, err := github.ParsePullRequestEvent(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	if !event.ShouldReview() {
+		return nil, nil
+	}
+	// Deliveries recorded before hosts were taken from the payload carry
+	// the unsigned header's host
+	if event.Repository.Host() != host {
+		return nil, fmt.Errorf("%w: %q, payload %q", ErrHostMismatch, host, event.Repository.Host())
+	}
+
+	return s.reviews.CreateReview(ctx, &model.ReviewRequest{
+		Host:       host,
+		PRNumber:   event.Number,
+		RepoOwner:  event.Repository.Owner.Login,
+		RepoName:   event.Repository.Name,
+		CommitHash: event.PullRequest.Head.SHA,
+	})
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository/memory"
+	"git-gud-bot/pkg/github"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayDeliveryClaimsOnce(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	s := NewWebhookService(nil, store)
+
+	_, err := store.CreateDelivery(ctx, &model.WebhookDelivery{
+		ID: "d-1", Event: "ping", Payload: []byte(`{}`), Status: model.DeliveryFailed,
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var replayed atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.ReplayDelivery(ctx, "d-1"); err == nil {
+				replayed.Add(1)
+			} else {
+				assert.ErrorIs(t, err, ErrDeliveryNotFailed)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), replayed.Load())
+
+	delivery, err := store.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryIgnored, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+
+	_, err = s.ReplayDelivery(ctx, "missing")
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+}
+
+func TestHandleDeliveryInFlight(t *testing.T) {
+	ctx := context.Background()
+	s := NewWebhookService(nil, memory.New())
+
+	_, err := s.HandleDelivery(ctx, "d-1", "", "ping", []byte(`{}`))
+	require.NoError(t, err)
+
+	delivery, err := s.HandleDelivery(ctx, "d-1", "", "ping", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrDuplicateDelivery)
+	assert.Equal(t, model.DeliveryIgnored, delivery.Status)
+}
+
+func TestHandleDeliveryRoutesByPayloadHost(t *testing.T) {
+	ctx := context.Background()
+	reviews, _ := newTestService(t, memory.New())
+	store := memory.New()
+	s := NewWebhookService(reviews, store)
+	payload := func(url string) []byte {
+		return []byte(`{"action": "opened", "number": 1,
+			"pull_request": {"head": {"sha": "head"}},
+			"repository": {"name": "app", "owner": {"login": "octo"}, "html_url": "` + url + `"}}`)
+	}
+
+	delivery, err := s.HandleDelivery(ctx, "d-1", "", github.EventPullRequest, payload("https://github.com/octo/app"))
+	require.NoError(t, err)
+	assert.Equal(t, github.DefaultHost, delivery.Host)
+	assert.NotEmpty(t, delivery.ReviewID)
+
+	// A captured delivery replayed with another host header is rejected
+	// before it is recorded
+	_, err = s.HandleDelivery(ctx, "d-2", "ghe.example.com", github.EventPullRequest, payload("https://github.com/octo/app"))
+	assert.ErrorIs(t, err, ErrHostMismatch)
+	_, err = store.GetDelivery(ctx, "d-2")
+	assert.Error(t, err)
+
+	// A stored delivery whose host came from the header is not trusted either
+	_, err = store.CreateDelivery(ctx, &model.WebhookDelivery{
+		ID: "d-3", Host: "ghe.example.com", Event: github.EventPullRequest,
+		Payload: payload("https://github.com/octo/app"), Status: model.DeliveryFailed,
+	})
+	require.NoError(t, err)
+	_, err = s.ReplayDelivery(ctx, "d-3")
+	assert.ErrorIs(t, err, ErrHostMismatch)
+}
@@ -0,0 +1,201 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository"
+	"git-gud-bot/internal/service"
+	"git-gud-bot/pkg/github"
+)
+
+// How long bookkeeping may take after the pool has been asked to stop
+const shutdownGrace = 5 * time.Second
+
+type Config struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Lease is how long a job may stay running before another worker may
+	// assume its worker died and claim it again
+	Lease time.Duration
+}
+
+// Pool runs queued review jobs on a fixed number of workers
+type Pool struct {
+	jobs    repository.JobStore
+	reviews *service.ReviewService
+	cfg     Config
+	wg      sync.WaitGroup
+
+	// jobCtx is what running jobs see; it outlives the context given to
+	// Start so that stopping the pool lets them finish
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+}
+
+func NewPool(jobs repository.JobStore, reviews *service.ReviewService, cfg Config) *Pool {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	return &Pool{
+		jobs:       jobs,
+		reviews:    reviews,
+		cfg:        cfg,
+		jobCtx:     jobCtx,
+		cancelJobs: cancelJobs,
+	}
+}
+
+// Start launches the workers. They stop claiming jobs once ctx is cancelled;
+// jobs already running carry on until Drain gives up on them.
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.run(ctx, i)
+	}
+}
+
+// Drain waits for the workers to exit once the context passed to Start is
+// cancelled. Jobs still running when ctx ends are cancelled and requeued,
+// and ctx's error is returned.
+func (p *Pool) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.cancelJobs()
+	<-done
+	return ctx.Err()
+}
+
+func (p *Pool) run(ctx context.Context, id int) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting for the next tick
+		for ctx.Err() == nil && p.runNext(ctx, id) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and runs a single job, reporting whether one was found
+func (p *Pool) runNext(ctx context.Context, id int) bool {
+	job, err := p.jobs.ClaimJob(ctx, p.cfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("worker %d: failed to claim job: %v", id, err)
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	// A job reclaimed after its lease expired may have crashed its worker
+	// every time; don't run it beyond its attempts
+	if job.Attempts > p.cfg.MaxAttempts {
+		p.abandon(ctx, id, job)
+		return true
+	}
+
+	runErr := p.reviews.ProcessReview(p.jobCtx, job.ReviewID)
+
+	// Record the outcome even if the pool is shutting down
+	doneCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownGrace)
+	defer cancel()
+
+	switch {
+	case runErr == nil:
+		err = p.jobs.CompleteJob(doneCtx, job.ID)
+	case p.jobCtx.Err() != nil:
+		// Cut short by Drain; whoever runs next picks it up straight away
+		log.Printf("worker %d: review %s interrupted by shutdown, requeued: %v", id, job.ReviewID, runErr)
+		err = p.jobs.RetryJob(doneCtx, job.ID, time.Now(), runErr.Error())
+	case isTransient(runErr) && job.Attempts < p.cfg.MaxAttempts:
+		delay := p.backoff(job.Attempts)
+		log.Printf("worker %d: review %s failed (attempt %d), retrying in %s: %v", id, job.ReviewID, job.Attempts, delay, runErr)
+		err = p.jobs.RetryJob(doneCtx, job.ID, time.Now().Add(delay), runErr.Error())
+	default:
+		log.Printf("worker %d: review %s failed permanently after %d attempts: %v", id, job.ReviewID, job.Attempts, runErr)
+		if markErr := p.reviews.MarkReviewFailed(doneCtx, job.ReviewID, runErr); markErr != nil {
+			log.Printf("worker %d: failed to mark review %s as failed: %v", id, job.ReviewID, markErr)
+		}
+		err = p.jobs.FailJob(doneCtx, job.ID, runErr.Error())
+	}
+
+	if err != nil {
+		log.Printf("worker %d: failed to update job %s: %v", id, job.ID, err)
+	}
+
+	return true
+}
+
+// abandon fails a job that ran out of attempts without finishing any of them
+func (p *Pool) abandon(ctx context.Context, id int, job *model.ReviewJob) {
+	cause := fmt.Errorf("abandoned after %d attempts that never finished", job.Attempts-1)
+	log.Printf("worker %d: review %s: %v", id, job.ReviewID, cause)
+
+	if err := p.reviews.MarkReviewFailed(ctx, job.ReviewID, cause); err != nil {
+		log.Printf("worker %d: failed to mark review %s as failed: %v", id, job.ReviewID, err)
+	}
+	if err := p.jobs.FailJob(ctx, job.ID, cause.Error()); err != nil {
+		log.Printf("worker %d: failed to update job %s: %v", id, job.ID, err)
+	}
+}
+
+// backoff returns an exponential delay with jitter for the given attempt
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.cfg.MaxBackoff
+	if attempt < 32 {
+		delay = p.cfg.BaseBackoff << (attempt - 1)
+	}
+	if delay <= 0 || delay > p.cfg.MaxBackoff {
+		delay = p.cfg.MaxBackoff
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isTransient reports whether a failed job is worth retrying. GitHub client
+// errors such as a missing pull request will not fix themselves, and neither
+// will a deleted review or one that fails validation.
+func isTransient(err error) bool {
+	var apiErr *github.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	var svcErr *service.Error
+	if errors.As(err, &svcErr) {
+		switch svcErr.Kind {
+		case service.KindNotFound, service.KindValidation, service.KindInvalidRequest:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git-gud-bot/internal/model"
+	"git-gud-bot/internal/repository/memory"
+	"git-gud-bot/internal/service"
+	"git-gud-bot/pkg/github"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	p := NewPool(nil, nil, Config{BaseBackoff: time.Second, MaxBackoff: time.Minute})
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: time.Minute, 64: time.Minute} {
+		delay := p.backoff(attempt)
+		assert.GreaterOrEqual(t, delay, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, delay, want, "attempt %d", attempt)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, isTransient(errors.New("connection reset")))
+	assert.True(t, isTransient(fmt.Errorf("wrapped: %w", &github.APIError{StatusCode: 502})))
+	assert.True(t, isTransient(&github.APIError{StatusCode: 429}))
+	assert.False(t, isTransient(fmt.Errorf("wrapped: %w", &github.APIError{StatusCode: 404})))
+
+	upstream := &service.Error{Kind: service.KindUpstream, Code: "github_unavailable", Err: &github.APIError{StatusCode: 502}}
+	assert.True(t, isTransient(upstream))
+	assert.False(t, isTransient(fmt.Errorf("failed to load review: %w", service.ErrReviewNotFound)))
+	assert.False(t, isTransient(fmt.Errorf("failed to process review: %w", service.ErrUnknownHost)))
+}
+
+func TestRunNextAbandonsExhaustedJobs(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	reviews := service.NewReviewService(store, github.NewHosts(), nil)
+
+	review := &model.Review{Host: "github.com", PRNumber: 1, RepoOwner: "octo", RepoName: "app", Status: model.StatusPending, CommitHash: "abc"}
+	require.NoError(t, store.CreateReviewWithJob(ctx, review, &model.ReviewJob{}))
+
+	// Every worker that claimed the job so far died before finishing it
+	const lease = -time.Second
+	for i := 0; i < 2; i++ {
+		job, err := store.ClaimJob(ctx, lease)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+	}
+
+	p := NewPool(store, reviews, Config{MaxAttempts: 2, Lease: lease})
+	assert.True(t, p.runNext(ctx, 0))
+
+	job, err := store.ClaimJob(ctx, lease)
+	require.NoError(t, err)
+	assert.Nil(t, job, "the abandoned job is failed, not requeued")
+
+	stored, err := store.GetReview(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, stored.Status)
+	assert.Contains(t, stored.Feedback, "abandoned after 2 attempts")
+
+	history, err := store.GetStatusHistory(ctx, review.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, model.ActorBot, history[0].Actor)
+}
+
+// newBlockingPool returns a pool with one queued job whose GitHub requests
+// block until release is closed, and a channel that receives once the job
+// is running
+func newBlockingPool(t *testing.T, store *memory.Store, release chan struct{}) (*Pool, <-chan struct{}) {
+	running := make(chan struct{}, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		running <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	hosts := github.NewHosts()
+	hosts.Add("", github.NewClient("token", github.WithEndpoints(github.Endpoints{BaseURL: server.URL, WebURL: server.URL})))
+	reviews := service.NewReviewService(store, hosts, nil)
+
+	review, err := reviews.CreateReview(context.Background(), &model.ReviewRequest{
+		Host: "127.0.0.1", PRNumber: 1, RepoOwner: "octo", RepoName: "app", CommitHash: "abc",
+	})
+	require.NoError(t, err)
+	require.Equal(t, model.StatusPending, review.Status)
+
+	p := NewPool(store, reviews, Config{Workers: 1, PollInterval: time.Hour, MaxAttempts: 1, BaseBackoff: time.Second, MaxBackoff: time.Second, Lease: time.Hour})
+	return p, running
+}
+
+func TestDrainRequeuesJobsThatOutlastIt(t *testing.T) {
+	store := memory.New()
+	release := make(chan struct{})
+	defer close(release)
+	p, running := newBlockingPool(t, store, release)
+
+	ctx, stop := context.WithCancel(context.Background())
+	p.Start(ctx)
+	<-running
+	stop()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Drain(drainCtx), context.DeadlineExceeded)
+
+	// The interrupted job is queued again even though it used its only
+	// attempt, and its review is not failed
+	job, err := store.ClaimJob(context.Background(), time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	review, err := store.GetReview(context.Background(), job.ReviewID)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPending, review.Status)
+}
+
+func TestDrainLetsRunningJobsFinish(t *testing.T) {
+	store := memory.New()
+	release := make(chan struct{})
+	p, running := newBlockingPool(t, store, release)
+
+	ctx, stop := context.WithCancel(context.Background())
+	p.Start(ctx)
+	<-running
+	stop()
+
+	// The job finishes within the drain period and records its outcome: the
+	// pull request doesn't exist, so it fails for good
+	close(release)
+	require.NoError(t, p.Drain(context.Background()))
+
+	job, err := store.ClaimJob(context.Background(), time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
@@ -2,14 +2,16 @@ package analyzer
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 
 	"git-gud-bot/pkg/github"
 )
 
 type CodeAnalyzer struct {
 	githubClient *github.Client
+	registry     *Registry
+	scoring      ScoringModel
 }
 
 type Analysis struct {
@@ -18,155 +20,226 @@ type Analysis struct {
 	BestPractices float64             `json:"best_practices"`
 	Issues        []Issue             `json:"issues"`
 	Metrics       map[string][]Metric `json:"metrics"`
+	Breakdown     []ScoreBreakdown    `json:"breakdown"`
 }
 
 type Issue struct {
+	Rule        string `json:"rule"`
 	File        string `json:"file"`
 	Line        int    `json:"line"`
 	Type        string `json:"type"`
+	Category    string `json:"category"`
 	Severity    string `json:"severity"`
 	Description string `json:"description"`
 }
 
+// Metric is a file-level measurement, or a per-function one when Function is set
 type Metric struct {
 	Name        string  `json:"name"`
+	Function    string  `json:"function,omitempty"`
+	Line        int     `json:"line,omitempty"`
 	Value       float64 `json:"value"`
 	Description string  `json:"description"`
 }
 
 func NewCodeAnalyzer(githubClient *github.Client) *CodeAnalyzer {
+	registry := NewRegistry()
+	registerDefaults(registry)
+
 	return &CodeAnalyzer{
 		githubClient: githubClient,
+		registry:     registry,
+		scoring:      DefaultScoringModel(),
 	}
 }
 
-func (a *CodeAnalyzer) AnalyzeCode(ctx context.Context, pr *github.PullRequest) (*Analysis, error) {
+// SetScoringModel replaces the default scoring model
+func (a *CodeAnalyzer) SetScoringModel(model ScoringModel) error {
+	if err := model.Validate(); err != nil {
+		return fmt.Errorf("invalid scoring model: %w", err)
+	}
+	a.scoring = model
+	return nil
+}
+
+// Registry exposes the analyzer's rules so callers can register their own or
+// switch built-in ones off
+func (a *CodeAnalyzer) Registry() *Registry {
+	return a.registry
+}
+
+// loadContents fetches the file at the head of the PR and, unless the PR adds
+// it, at the base commit under its previous name
+func (a *CodeAnalyzer) loadContents(ctx context.Context, fc *FileContext) error {
+	owner, repo := fc.PR.Base.Repo.Owner.Login, fc.PR.Base.Repo.Name
+
+	head, err := a.githubClient.GetFileContents(ctx, owner, repo, fc.File.Name, fc.PR.Head.SHA)
+	if err != nil {
+		return fmt.Errorf("failed to fetch contents: %w", err)
+	}
+	fc.Content = head
+
+	if fc.File.Status == "added" {
+		return nil
+	}
+
+	baseName := fc.File.Name
+	if fc.File.PreviousName != "" {
+		baseName = fc.File.PreviousName
+	}
+	base, err := a.githubClient.GetFileContents(ctx, owner, repo, baseName, fc.PR.Base.SHA)
+	if err != nil {
+		return fmt.Errorf("failed to fetch base contents: %w", err)
+	}
+	fc.BaseContent = base
+
+	return nil
+}
+
+// WithClient returns a copy of the analyzer that fetches files through
+// client, for pull requests on another GitHub host. The copy shares the
+// registry and scoring model.
+func (a *CodeAnalyzer) WithClient(client *github.Client) *CodeAnalyzer {
+	copied := *a
+	copied.githubClient = client
+	return &copied
+}
+
+// fileWideRules report problems that break the whole file, so their issues
+// are kept even when the line they point at was not changed, e.g. a parse
+// error reported at the end of the file
+var fileWideRules = map[string]bool{
+	"go/syntax": true,
+}
+
+// registerDefaults registers the built-in languages and rules. The generic
+// language matches everything and must stay last.
+func registerDefaults(registry *Registry) {
+	registry.RegisterLanguage(&goLanguage{})
+	registry.RegisterLanguage(&extensionLanguage{language: LanguageJavaScript, extensions: []string{".js"}})
+	registry.RegisterLanguage(&extensionLanguage{language: LanguagePython, extensions: []string{".py"}})
+	registry.RegisterLanguage(&extensionLanguage{language: LanguageGeneric})
+
+	rules := []Rule{
+		NewRule("go/syntax", LanguageGo, checkGoSyntax),
+		NewRule("go/function-metrics", LanguageGo, checkGoFunctionMetrics),
+		NewMetadataRule("generic/churn", LanguageGeneric, checkChurn),
+	}
+	rules = append(rules, goIssueRules()...)
+
+	for _, rule := range rules {
+		if err := registry.RegisterRule(rule); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (a *CodeAnalyzer) AnalyzeCode(ctx context.Context, pr *github.PullRequest, opts Options) (*Analysis, error) {
 	analysis := &Analysis{
 		Metrics: make(map[string][]Metric),
 		Issues:  make([]Issue, 0),
 	}
 
 	// Analyze each file in the PR
+	changedLines := 0
 	for _, file := range pr.Files {
-		if err := a.analyzeFile(ctx, file, analysis); err != nil {
+		if opts.Ignore != nil && opts.Ignore(file.Name) {
+			continue
+		}
+		if err := a.analyzeFile(ctx, pr, file, opts, analysis); err != nil {
 			return nil, fmt.Errorf("failed to analyze file %s: %w", file.Name, err)
 		}
+		if file.Status != "removed" {
+			changedLines += file.Additions
+		}
 	}
 
 	// Calculate overall scores
-	analysis.CodeQuality = a.calculateCodeQuality(analysis)
-	analysis.Performance = a.calculatePerformance(analysis)
-	analysis.BestPractices = a.calculateBestPractices(analysis)
+	a.scoring.Score(analysis, changedLines)
 
 	return analysis, nil
 }
 
-func (a *CodeAnalyzer) analyzeFile(ctx context.Context, file github.File, analysis *Analysis) error {
+func (a *CodeAnalyzer) analyzeFile(ctx context.Context, pr *github.PullRequest, file github.File, opts Options, analysis *Analysis) error {
 	// Skip deleted files
 	if file.Status == "removed" {
 		return nil
 	}
 
-	// Analyze based on file type
-	switch {
-	case strings.HasSuffix(file.Name, ".go"):
-		return a.analyzeGoFile(ctx, file, analysis)
-	case strings.HasSuffix(file.Name, ".js"):
-		return a.analyzeJavaScriptFile(ctx, file, analysis)
-	case strings.HasSuffix(file.Name, ".py"):
-		return a.analyzePythonFile(ctx, file, analysis)
-	default:
-		// Basic analysis for other file types
-		return a.analyzeGenericFile(ctx, file, analysis)
+	language, ok := a.registry.LanguageFor(file.Name)
+	if !ok {
+		return nil
 	}
-}
-
-func (a *CodeAnalyzer) analyzeGoFile(_ context.Context, file github.File, analysis *Analysis) error {
-	metrics := []Metric{
-		{
-			Name:        "function_length",
-			Value:       a.calculateAverageFunctionLength(file),
-			Description: "Average function length in lines",
-		},
-		{
-			Name:        "cyclomatic_complexity",
-			Value:       a.calculateCyclomaticComplexity(file),
-			Description: "Cyclomatic complexity score",
-		},
-		{
-			Name:        "test_coverage",
-			Value:       a.calculateTestCoverage(file),
-			Description: "Test coverage percentage",
-		},
-	}
-
-	analysis.Metrics[file.Name] = metrics
-	analysis.Issues = append(analysis.Issues, a.findGoIssues(file)...)
-
-	return nil
-}
-
-func (a *CodeAnalyzer) analyzeJavaScriptFile(_ context.Context, _ github.File, _ *Analysis) error {
-	// TODO: Implement JavaScript-specific analysis
-	return nil
-}
 
-func (a *CodeAnalyzer) analyzePythonFile(_ context.Context, _ github.File, _ *Analysis) error {
-	// TODO: Implement Python-specific analysis
-	return nil
-}
-
-func (a *CodeAnalyzer) analyzeGenericFile(_ context.Context, file github.File, analysis *Analysis) error {
-	metrics := []Metric{
-		{
-			Name:        "file_size",
-			Value:       float64(file.Changes),
-			Description: "File size in lines",
-		},
-		{
-			Name:        "churn",
-			Value:       float64(file.Additions + file.Deletions),
-			Description: "Code churn (additions + deletions)",
-		},
-	}
-
-	analysis.Metrics[file.Name] = metrics
-	return nil
-}
-
-func (a *CodeAnalyzer) calculateCodeQuality(_ *Analysis) float64 {
-	// TODO: Implement proper code quality calculation
-	return 85.0 // Placeholder
-}
+	rules := a.registry.rulesFor(language.Language(), opts)
+	if len(rules) == 0 {
+		return nil
+	}
 
-func (a *CodeAnalyzer) calculatePerformance(_ *Analysis) float64 {
-	// TODO: Implement proper performance calculation
-	return 90.0 // Placeholder
-}
+	fc := &FileContext{
+		GitHub: a.githubClient,
+		PR:     pr,
+		File:   file,
+		Added:  addedLines(file.Patch),
+	}
+	if needsContent(rules) {
+		if err := a.loadContents(ctx, fc); err != nil {
+			if retryable(ctx, err) {
+				return err
+			}
+			// Submodules, symlinks and blobs too large for the API can't be
+			// analyzed; leave them out rather than fail the whole PR
+			return nil
+		}
+		if err := language.Load(ctx, fc); err != nil {
+			return err
+		}
+	}
 
-func (a *CodeAnalyzer) calculateBestPractices(_ *Analysis) float64 {
-	// TODO: Implement proper best practices calculation
-	return 88.0 // Placeholder
-}
+	for _, rule := range rules {
+		issues, metrics, err := rule.Check(ctx, fc)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name(), err)
+		}
 
-// Helper functions
+		// Only report on what the PR changed
+		for _, issue := range issues {
+			if issue.Rule == "" {
+				issue.Rule = rule.Name()
+			}
+			if issue.Line > 0 && !fc.Changed(issue.Line) && !fileWideRules[issue.Rule] {
+				continue
+			}
+			if severity, ok := opts.SeverityOverrides[issue.Rule]; ok {
+				issue.Severity = severity
+			}
+			analysis.Issues = append(analysis.Issues, issue)
+		}
 
-func (a *CodeAnalyzer) calculateAverageFunctionLength(_ github.File) float64 {
-	// TODO: Implement actual calculation
-	return 15.0 // Placeholder
-}
+		if len(metrics) > 0 {
+			analysis.Metrics[file.Name] = append(analysis.Metrics[file.Name], metrics...)
+		}
+	}
 
-func (a *CodeAnalyzer) calculateCyclomaticComplexity(_ github.File) float64 {
-	// TODO: Implement actual calculation
-	return 5.0 // Placeholder
+	return nil
 }
 
-func (a *CodeAnalyzer) calculateTestCoverage(_ github.File) float64 {
-	// TODO: Implement actual calculation
-	return 80.0 // Placeholder
+// needsContent reports whether any of the rules reads file contents
+func needsContent(rules []Rule) bool {
+	for _, rule := range rules {
+		if cr, ok := rule.(ContentRule); !ok || cr.NeedsContent() {
+			return true
+		}
+	}
+	return false
 }
 
-func (a *CodeAnalyzer) findGoIssues(_ github.File) []Issue {
-	// TODO: Implement actual issue detection
-	return []Issue{} // Placeholder
+// retryable reports whether a failed fetch should fail the analysis so that
+// it is tried again later, rather than skip the file
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	var apiErr *github.APIError
+	return errors.As(err, &apiErr) && apiErr.Temporary()
 }
@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"git-gud-bot/pkg/github"
+	"git-gud-bot/pkg/github/githubtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRepo = github.Repository{Name: "app", Owner: github.User{Login: "octo"}}
+
+func TestAnalyzeCodeLoadsBaseAndHead(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	pr := github.PullRequest{
+		Number: 1,
+		Head:   github.Branch{SHA: "head"},
+		Base:   github.Branch{SHA: "base", Repo: testRepo},
+		Files: []github.File{
+			{Name: "main.go", Status: "modified"},
+			{Name: "new.go", PreviousName: "old.go", Status: "renamed"},
+			{Name: "added.go", Status: "added"},
+			{Name: "big.txt", Status: "modified"},
+		},
+	}
+	big := bytes.Repeat([]byte("x"), 2<<20)
+
+	server.AddFile("octo", "app", "base", "main.go", []byte("package main // before\n"))
+	server.AddFile("octo", "app", "head", "main.go", []byte("package main // after\n"))
+	server.AddFile("octo", "app", "base", "old.go", []byte("package old\n"))
+	server.AddFile("octo", "app", "head", "new.go", []byte("package renamed\n"))
+	server.AddFile("octo", "app", "head", "added.go", []byte("package added\n"))
+	server.AddFile("octo", "app", "", "big.txt", big)
+
+	type loaded struct{ base, head string }
+	seen := make(map[string]loaded)
+	capture := func(_ context.Context, fc *FileContext) ([]Issue, []Metric, error) {
+		seen[fc.File.Name] = loaded{base: string(fc.BaseContent), head: string(fc.Content)}
+		return nil, nil, nil
+	}
+
+	a := NewCodeAnalyzer(server.Client())
+	for _, language := range []Language{LanguageGo, LanguageGeneric} {
+		require.NoError(t, a.Registry().RegisterRule(NewRule("test/capture-"+string(language), language, capture)))
+	}
+
+	_, err := a.AnalyzeCode(context.Background(), &pr, Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, loaded{"package main // before\n", "package main // after\n"}, seen["main.go"])
+	assert.Equal(t, loaded{"package old\n", "package renamed\n"}, seen["new.go"])
+	assert.Equal(t, loaded{"", "package added\n"}, seen["added.go"])
+
+	assert.Len(t, seen["big.txt"].head, len(big), "large files come from the blob API")
+	assert.Len(t, seen["big.txt"].base, len(big))
+}
+
+func TestAnalyzeCodeFetchesOnlyWhenNeeded(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	// Neither file exists on the server, so any fetch would skip the file
+	pr := github.PullRequest{
+		Number: 1,
+		Head:   github.Branch{SHA: "head"},
+		Base:   github.Branch{SHA: "base", Repo: testRepo},
+		Files: []github.File{
+			{Name: "README.md", Status: "modified", Additions: 3, Deletions: 1, Changes: 4},
+			{Name: "vendor", Status: "modified", Additions: 1, Deletions: 1, Changes: 2},
+		},
+	}
+
+	analysis, err := NewCodeAnalyzer(server.Client()).AnalyzeCode(context.Background(), &pr, Options{})
+	require.NoError(t, err)
+
+	assert.Contains(t, analysis.Metrics, "README.md")
+	assert.Contains(t, analysis.Metrics, "vendor")
+}
+
+func TestAnalyzeCodeSkipsUnfetchableFiles(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	pr := github.PullRequest{
+		Number: 1,
+		Head:   github.Branch{SHA: "head"},
+		Base:   github.Branch{SHA: "base", Repo: testRepo},
+		Files: []github.File{
+			{Name: "submodule.go", Status: "added"},
+			{Name: "main.go", Status: "added"},
+		},
+	}
+	server.AddFile("octo", "app", "head", "main.go", []byte("package main\n\nfunc main() {}\n"))
+
+	analysis, err := NewCodeAnalyzer(server.Client()).AnalyzeCode(context.Background(), &pr, Options{})
+	require.NoError(t, err)
+
+	assert.NotContains(t, analysis.Metrics, "submodule.go")
+	assert.Contains(t, analysis.Metrics, "main.go")
+
+	// A cancelled analysis fails instead of skipping every file
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewCodeAnalyzer(server.Client()).AnalyzeCode(ctx, &pr, Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryable(t *testing.T) {
+	ctx := context.Background()
+
+	assert.True(t, retryable(ctx, &github.APIError{StatusCode: 502}))
+	assert.True(t, retryable(ctx, &github.APIError{StatusCode: 403, RateLimited: true}))
+	assert.False(t, retryable(ctx, &github.APIError{StatusCode: 404}))
+	assert.False(t, retryable(ctx, errors.New("submodule is a submodule, not a file")))
+}
+
+func TestAnalyzeCodeKeepsSyntaxErrors(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	// The missing brace is only noticed at the end of the file, on a line
+	// the PR didn't add
+	pr := github.PullRequest{
+		Number: 1,
+		Head:   github.Branch{SHA: "head"},
+		Base:   github.Branch{SHA: "base", Repo: testRepo},
+		Files: []github.File{
+			{Name: "main.go", Status: "modified", Patch: "@@ -1,3 +1,4 @@\n package main\n \n+func main() {\n \n"},
+		},
+	}
+	server.AddFile("octo", "app", "base", "main.go", []byte("package main\n\n\n"))
+	server.AddFile("octo", "app", "head", "main.go", []byte("package main\n\nfunc main() {\n\n"))
+
+	analysis, err := NewCodeAnalyzer(server.Client()).AnalyzeCode(context.Background(), &pr, Options{})
+	require.NoError(t, err)
+
+	require.Len(t, analysis.Issues, 1)
+	assert.Equal(t, "go/syntax", analysis.Issues[0].Rule)
+	assert.Greater(t, analysis.Issues[0].Line, 3)
+}
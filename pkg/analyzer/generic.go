@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+)
+
+// extensionLanguage matches files by extension and needs no preparation.
+// With no extensions it matches every file.
+type extensionLanguage struct {
+	language   Language
+	extensions []string
+}
+
+func (l *extensionLanguage) Language() Language {
+	return l.language
+}
+
+func (l *extensionLanguage) Match(filename string) bool {
+	if len(l.extensions) == 0 {
+		return true
+	}
+	for _, ext := range l.extensions {
+		if strings.HasSuffix(filename, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *extensionLanguage) Load(_ context.Context, _ *FileContext) error {
+	return nil
+}
+
+// checkChurn reports size and churn for files without a dedicated analyzer
+func checkChurn(_ context.Context, fc *FileContext) ([]Issue, []Metric, error) {
+	metrics := []Metric{
+		{
+			Name:        "file_size",
+			Value:       float64(fc.File.Changes),
+			Description: "File size in lines",
+		},
+		{
+			Name:        "churn",
+			Value:       float64(fc.File.Additions + fc.File.Deletions),
+			Description: "Code churn (additions + deletions)",
+		},
+	}
+
+	return nil, metrics, nil
+}
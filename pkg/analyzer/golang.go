@@ -0,0 +1,250 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"git-gud-bot/pkg/github"
+)
+
+// goLanguage fetches, parses and type-checks Go files for the Go rules
+type goLanguage struct{}
+
+func (l *goLanguage) Language() Language {
+	return LanguageGo
+}
+
+func (l *goLanguage) Match(filename string) bool {
+	return strings.HasSuffix(filename, ".go")
+}
+
+func (l *goLanguage) Load(_ context.Context, fc *FileContext) error {
+	fset, file, err := parseGoFile(fc.File.Name, fc.Content)
+	if err != nil {
+		fc.GoError = err
+		return nil
+	}
+	fc.Go = checkGoSource(fc.File.Name, fset, file)
+
+	return nil
+}
+
+// checkGoSyntax reports files that do not parse; other Go rules skip them
+func checkGoSyntax(_ context.Context, fc *FileContext) ([]Issue, []Metric, error) {
+	if fc.GoError == nil {
+		return nil, nil, nil
+	}
+
+	issue := Issue{
+		File:        fc.File.Name,
+		Type:        "syntax_error",
+		Category:    CategoryCodeQuality,
+		Severity:    SeverityHigh,
+		Description: "File does not parse: " + fc.GoError.Error(),
+	}
+	var list scanner.ErrorList
+	if errors.As(fc.GoError, &list) && len(list) > 0 {
+		issue.Line = list[0].Pos.Line
+	}
+
+	return []Issue{issue}, nil, nil
+}
+
+// checkGoFunctionMetrics reports length and complexity for every function
+// the PR touched, plus their averages for the file. There is deliberately no
+// test_coverage metric: the one this replaced was a constant placeholder, and
+// measuring coverage means running the PR's tests, which the analyzer does not.
+func checkGoFunctionMetrics(_ context.Context, fc *FileContext) ([]Issue, []Metric, error) {
+	if fc.Go == nil {
+		return nil, nil, nil
+	}
+
+	functions := touchedFunctions(goFunctions(fc.Go.Fset, fc.Go.File), fc.Added)
+
+	metrics := []Metric{
+		{
+			Name:        "function_length",
+			Value:       averageLength(functions),
+			Description: "Average length in lines of changed functions",
+		},
+		{
+			Name:        "cyclomatic_complexity",
+			Value:       averageComplexity(functions),
+			Description: "Average cyclomatic complexity of changed functions",
+		},
+	}
+
+	for _, fn := range functions {
+		metrics = append(metrics,
+			Metric{
+				Name:        "function_length",
+				Function:    fn.Name,
+				Line:        fn.StartLine,
+				Value:       float64(fn.Length()),
+				Description: "Function length in lines",
+			},
+			Metric{
+				Name:        "cyclomatic_complexity",
+				Function:    fn.Name,
+				Line:        fn.StartLine,
+				Value:       float64(fn.Complexity),
+				Description: "McCabe cyclomatic complexity",
+			},
+		)
+	}
+
+	return nil, metrics, nil
+}
+
+// goFunction holds the metrics computed for a single function declaration
+type goFunction struct {
+	Name       string
+	StartLine  int
+	EndLine    int
+	Complexity int
+}
+
+func (f goFunction) Length() int {
+	return f.EndLine - f.StartLine + 1
+}
+
+func parseGoFile(name string, src []byte) (*token.FileSet, *ast.File, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fset, file, nil
+}
+
+// goFunctions returns every function declared in the file
+func goFunctions(fset *token.FileSet, file *ast.File) []goFunction {
+	var functions []goFunction
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		functions = append(functions, goFunction{
+			Name:       funcName(fn),
+			StartLine:  fset.Position(fn.Pos()).Line,
+			EndLine:    fset.Position(fn.End()).Line,
+			Complexity: cyclomaticComplexity(fn),
+		})
+	}
+	return functions
+}
+
+// addedLines returns the lines added by a file's patch, or nil when there is
+// no usable patch (binary or oversized diffs) and every line should count
+func addedLines(patch string) map[int]bool {
+	if patch == "" {
+		return nil
+	}
+
+	parsed, err := github.ParsePatch(patch)
+	if err != nil {
+		return nil
+	}
+	return parsed.AddedLines()
+}
+
+// touchedFunctions filters functions to those containing an added line
+func touchedFunctions(functions []goFunction, added map[int]bool) []goFunction {
+	if added == nil {
+		return functions
+	}
+
+	var touched []goFunction
+	for _, fn := range functions {
+		for line := fn.StartLine; line <= fn.EndLine; line++ {
+			if added[line] {
+				touched = append(touched, fn)
+				break
+			}
+		}
+	}
+	return touched
+}
+
+// cyclomaticComplexity computes the McCabe complexity of a function: one plus
+// the number of decision points. Function literals count toward the function
+// that contains them.
+func cyclomaticComplexity(fn *ast.FuncDecl) int {
+	complexity := 1
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// funcName returns the qualified name of a function, e.g. "(*Client).Do"
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		return "(*" + typeName(star.X) + ")." + fn.Name.Name
+	}
+	return typeName(recv) + "." + fn.Name.Name
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return typeName(t.X)
+	case *ast.IndexListExpr:
+		return typeName(t.X)
+	default:
+		return "?"
+	}
+}
+
+func averageLength(functions []goFunction) float64 {
+	if len(functions) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, fn := range functions {
+		total += fn.Length()
+	}
+	return float64(total) / float64(len(functions))
+}
+
+func averageComplexity(functions []goFunction) float64 {
+	if len(functions) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, fn := range functions {
+		total += fn.Complexity
+	}
+	return float64(total) / float64(len(functions))
+}
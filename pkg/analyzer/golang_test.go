@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleGoFile = `package sample
+
+func simple() int {
+	return 1
+}
+
+func (s *Server) branchy(xs []int, ok bool) int {
+	total := 0
+	for _, x := range xs {
+		if x > 0 && ok {
+			total += x
+		}
+	}
+	switch {
+	case total > 10:
+		return 10
+	case total < 0 || !ok:
+		return 0
+	default:
+		return total
+	}
+}
+`
+
+func TestGoFunctions(t *testing.T) {
+	fset, file, err := parseGoFile("sample.go", []byte(sampleGoFile))
+	require.NoError(t, err)
+
+	functions := goFunctions(fset, file)
+	require.Len(t, functions, 2)
+
+	assert.Equal(t, goFunction{Name: "simple", StartLine: 3, EndLine: 5, Complexity: 1}, functions[0])
+	assert.Equal(t, "(*Server).branchy", functions[1].Name)
+	assert.Equal(t, 16, functions[1].Length())
+	// range, if, &&, two non-default cases and ||
+	assert.Equal(t, 7, functions[1].Complexity)
+}
+
+func TestTouchedFunctions(t *testing.T) {
+	fset, file, err := parseGoFile("sample.go", []byte(sampleGoFile))
+	require.NoError(t, err)
+	functions := goFunctions(fset, file)
+
+	patch := "@@ -8,3 +8,3 @@ func (s *Server) branchy(xs []int, ok bool) int {\n \ttotal := 0\n-\tfor _, x := range ys {\n+\tfor _, x := range xs {\n \t\tif x > 0 && ok {"
+	touched := touchedFunctions(functions, addedLines(patch))
+	require.Len(t, touched, 1)
+	assert.Equal(t, "(*Server).branchy", touched[0].Name)
+
+	assert.Len(t, touchedFunctions(functions, addedLines("")), 2)
+}
@@ -0,0 +1,481 @@
+package analyzer
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Issue types reported by the Go rules
+const (
+	IssueIgnoredError        = "ignored_error"
+	IssueDeferInLoop         = "defer_in_loop"
+	IssueUnclosedBody        = "unclosed_response_body"
+	IssueContextBackground   = "context_background"
+	IssueShadowedErr         = "shadowed_err"
+	IssueSQLStringFormatting = "sql_string_formatting"
+)
+
+var sqlStatement = regexp.MustCompile(`(?is)\b(select\s.+\sfrom|insert\s+into|update\s+\S+\s+set|delete\s+from)\b`)
+
+// GoSource is a parsed and type-checked Go file. Imports are not resolved, so
+// type information is only available for identifiers declared in the file.
+type GoSource struct {
+	Fset *token.FileSet
+	File *ast.File
+	// Info holds the types, definitions, uses and scopes the checker found
+	Info *types.Info
+
+	name string
+}
+
+func checkGoSource(name string, fset *token.FileSet, file *ast.File) *GoSource {
+	info := &types.Info{
+		Types:  make(map[ast.Expr]types.TypeAndValue),
+		Defs:   make(map[*ast.Ident]types.Object),
+		Uses:   make(map[*ast.Ident]types.Object),
+		Scopes: make(map[ast.Node]*types.Scope),
+	}
+
+	conf := types.Config{
+		Importer: stubImporter{},
+		// Unresolved imports produce errors; keep whatever could be checked
+		Error: func(error) {},
+	}
+	_, _ = conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+
+	return &GoSource{name: name, Fset: fset, File: file, Info: info}
+}
+
+// stubImporter satisfies every import with an empty package so a single file
+// can be type-checked without access to its dependencies
+type stubImporter struct{}
+
+func (stubImporter) Import(importPath string) (*types.Package, error) {
+	name := path.Base(importPath)
+	if strings.HasPrefix(name, "v") && path.Dir(importPath) != "." {
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			name = path.Base(path.Dir(importPath))
+		}
+	}
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+
+	pkg := types.NewPackage(importPath, name)
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+func (s *GoSource) issue(node ast.Node, issueType, category, severity, description string) Issue {
+	return Issue{
+		File:        s.name,
+		Line:        s.Fset.Position(node.Pos()).Line,
+		Type:        issueType,
+		Category:    category,
+		Severity:    severity,
+		Description: description,
+	}
+}
+
+// importName returns the name a package is imported under, or "" if the file
+// does not import it
+func (s *GoSource) importName(importPath string) string {
+	for _, spec := range s.File.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p != importPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return path.Base(importPath)
+	}
+	return ""
+}
+
+// isPkgCall reports whether call is pkgName.<one of funcs>(...)
+func isPkgCall(call *ast.CallExpr, pkgName string, funcs ...string) bool {
+	if pkgName == "" {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok || ident.Name != pkgName {
+		return false
+	}
+	for _, fn := range funcs {
+		if sel.Sel.Name == fn {
+			return true
+		}
+	}
+	return false
+}
+
+// goIssueRules returns the built-in Go issue rules
+func goIssueRules() []Rule {
+	return []Rule{
+		NewRule("go/ignored-error", LanguageGo, goIssueCheck(findIgnoredErrors)),
+		NewRule("go/defer-in-loop", LanguageGo, goIssueCheck(findDeferInLoop)),
+		NewRule("go/unclosed-body", LanguageGo, goIssueCheck(findUnclosedBodies)),
+		NewRule("go/context-background", LanguageGo, goIssueCheck(findContextBackground)),
+		NewRule("go/shadowed-err", LanguageGo, goIssueCheck(findShadowedErr)),
+		NewRule("go/sql-sprintf", LanguageGo, goIssueCheck(findSQLStringFormatting)),
+	}
+}
+
+// goIssueCheck adapts a finder to a CheckFunc, skipping unparsable files
+func goIssueCheck(find func(*GoSource) []Issue) CheckFunc {
+	return func(_ context.Context, fc *FileContext) ([]Issue, []Metric, error) {
+		if fc.Go == nil {
+			return nil, nil, nil
+		}
+		return find(fc.Go), nil, nil
+	}
+}
+
+// findIgnoredErrors flags errors discarded with the blank identifier and calls
+// to local functions whose error result is dropped entirely
+func findIgnoredErrors(s *GoSource) []Issue {
+	var issues []Issue
+
+	ast.Inspect(s.File, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Rhs) != 1 {
+				return true
+			}
+			call, ok := n.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			last, ok := n.Lhs[len(n.Lhs)-1].(*ast.Ident)
+			if !ok || last.Name != "_" {
+				return true
+			}
+
+			// Use the call's type when it is known. Calls into other packages
+			// cannot be resolved, so assume a blank final result is an error.
+			known, returnsErr := lastResultIsError(s.Info, call)
+			if known && !returnsErr {
+				return true
+			}
+			if !known && len(n.Lhs) == 1 {
+				return true
+			}
+			issues = append(issues, s.issue(n, IssueIgnoredError, CategoryCodeQuality, SeverityMedium,
+				"Error result is discarded with _; handle it or explain why it is safe to ignore"))
+
+		case *ast.ExprStmt:
+			call, ok := n.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if known, returnsErr := lastResultIsError(s.Info, call); known && returnsErr {
+				issues = append(issues, s.issue(n, IssueIgnoredError, CategoryCodeQuality, SeverityMedium,
+					"Error returned by this call is never checked"))
+			}
+		}
+		return true
+	})
+
+	return issues
+}
+
+// lastResultIsError reports whether the call's type is known and, if so,
+// whether its last result is the error type
+func lastResultIsError(info *types.Info, call *ast.CallExpr) (known, isErr bool) {
+	tv, ok := info.Types[call]
+	if !ok || tv.Type == nil || tv.Type == types.Typ[types.Invalid] {
+		return false, false
+	}
+
+	t := tv.Type
+	if tuple, ok := t.(*types.Tuple); ok {
+		if tuple.Len() == 0 {
+			return true, false
+		}
+		t = tuple.At(tuple.Len() - 1).Type()
+	}
+	if t == types.Typ[types.Invalid] {
+		return false, false
+	}
+
+	return true, types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// findDeferInLoop flags defer statements that only run when the enclosing
+// function returns, not at the end of each iteration
+func findDeferInLoop(s *GoSource) []Issue {
+	var issues []Issue
+
+	var visit func(n ast.Node, inLoop bool)
+	visit = func(n ast.Node, inLoop bool) {
+		ast.Inspect(n, func(child ast.Node) bool {
+			if child == n {
+				return true
+			}
+			switch child := child.(type) {
+			case *ast.FuncLit:
+				// A closure starts a new defer scope
+				visit(child.Body, false)
+				return false
+			case *ast.ForStmt:
+				visit(child.Body, true)
+				return false
+			case *ast.RangeStmt:
+				visit(child.Body, true)
+				return false
+			case *ast.DeferStmt:
+				if inLoop {
+					issues = append(issues, s.issue(child, IssueDeferInLoop, CategoryPerformance, SeverityMedium,
+						"defer inside a loop runs when the function returns, not per iteration; move the body into a function"))
+				}
+			}
+			return true
+		})
+	}
+	visit(s.File, false)
+
+	return issues
+}
+
+// findUnclosedBodies flags HTTP responses whose Body is never closed in the
+// function that obtained them
+func findUnclosedBodies(s *GoSource) []Issue {
+	httpName := s.importName("net/http")
+	var issues []Issue
+
+	forEachFunc(s.File, func(body *ast.BlockStmt) {
+		ast.Inspect(body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false
+			}
+			assign, ok := n.(*ast.AssignStmt)
+			if !ok || len(assign.Rhs) != 1 || len(assign.Lhs) == 0 {
+				return true
+			}
+			call, ok := assign.Rhs[0].(*ast.CallExpr)
+			if !ok || !isHTTPRequestCall(call, httpName) {
+				return true
+			}
+			resp, ok := assign.Lhs[0].(*ast.Ident)
+			if !ok || resp.Name == "_" {
+				return true
+			}
+			if !bodyClosedOrHandedOff(body, resp.Name) {
+				issues = append(issues, s.issue(assign, IssueUnclosedBody, CategoryPerformance, SeverityHigh,
+					"Response body of "+resp.Name+" is never closed; add defer "+resp.Name+".Body.Close()"))
+			}
+			return true
+		})
+	})
+
+	return issues
+}
+
+func isHTTPRequestCall(call *ast.CallExpr, httpName string) bool {
+	if isPkgCall(call, httpName, "Get", "Head", "Post", "PostForm") {
+		return true
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Do" && len(call.Args) == 1
+}
+
+// bodyClosedOrHandedOff reports whether name.Body.Close() is called, or the
+// response is returned or passed on so closing it is someone else's job
+func bodyClosedOrHandedOff(body *ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Close" {
+				if inner, ok := sel.X.(*ast.SelectorExpr); ok && inner.Sel.Name == "Body" && isIdent(inner.X, name) {
+					found = true
+				}
+			}
+			for _, arg := range n.Args {
+				if isIdent(arg, name) {
+					found = true
+				}
+			}
+		case *ast.ReturnStmt:
+			for _, result := range n.Results {
+				if isIdent(result, name) {
+					found = true
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// findContextBackground flags fresh root contexts created inside functions
+// that were handed a context they should propagate instead
+func findContextBackground(s *GoSource) []Issue {
+	contextName := s.importName("context")
+	if contextName == "" {
+		return nil
+	}
+	var issues []Issue
+
+	ast.Inspect(s.File, func(n ast.Node) bool {
+		var fnType *ast.FuncType
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			fnType, body = fn.Type, fn.Body
+		case *ast.FuncLit:
+			fnType, body = fn.Type, fn.Body
+		default:
+			return true
+		}
+		if body == nil || !acceptsContext(fnType, contextName) {
+			return true
+		}
+
+		ast.Inspect(body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && isPkgCall(call, contextName, "Background", "TODO") {
+				issues = append(issues, s.issue(call, IssueContextBackground, CategoryBestPractices, SeverityMedium,
+					"Function receives a context but creates a new root context; pass the incoming ctx to keep cancellation and deadlines"))
+			}
+			return true
+		})
+		// Nested functions were covered by the walk above
+		return false
+	})
+
+	return issues
+}
+
+func acceptsContext(fnType *ast.FuncType, contextName string) bool {
+	if fnType.Params == nil {
+		return false
+	}
+	for _, field := range fnType.Params.List {
+		sel, ok := field.Type.(*ast.SelectorExpr)
+		if ok && sel.Sel.Name == "Context" && isIdent(sel.X, contextName) {
+			return true
+		}
+	}
+	return false
+}
+
+// findShadowedErr flags err variables declared in a nested block while an
+// err from an enclosing block is in scope, so assignments never reach the
+// outer variable. The `if err := f(); err != nil` idiom is not reported.
+func findShadowedErr(s *GoSource) []Issue {
+	initDecls := make(map[*ast.Ident]bool)
+	ast.Inspect(s.File, func(n ast.Node) bool {
+		var init ast.Stmt
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			init = n.Init
+		case *ast.SwitchStmt:
+			init = n.Init
+		case *ast.TypeSwitchStmt:
+			init = n.Init
+		}
+		if assign, ok := init.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					initDecls[ident] = true
+				}
+			}
+		}
+		return true
+	})
+
+	var issues []Issue
+	ast.Inspect(s.File, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE {
+			return true
+		}
+		for _, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			if !ok || ident.Name != "err" || initDecls[ident] {
+				continue
+			}
+			obj := s.Info.Defs[ident]
+			if obj == nil || obj.Parent() == nil || obj.Parent().Parent() == nil {
+				continue
+			}
+			_, outer := obj.Parent().Parent().LookupParent("err", ident.Pos())
+			if outer == nil || outer.Parent() == types.Universe || outer.Parent() == outer.Pkg().Scope() {
+				continue
+			}
+			issues = append(issues, s.issue(ident, IssueShadowedErr, CategoryCodeQuality, SeverityMedium,
+				"err shadows an err declared in an enclosing scope; errors assigned here will not be seen outside this block"))
+		}
+		return true
+	})
+
+	return issues
+}
+
+// findSQLStringFormatting flags SQL statements assembled with fmt.Sprintf,
+// which invites injection; use query placeholders instead
+func findSQLStringFormatting(s *GoSource) []Issue {
+	fmtName := s.importName("fmt")
+	var issues []Issue
+
+	ast.Inspect(s.File, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 || !isPkgCall(call, fmtName, "Sprintf") {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		format, err := strconv.Unquote(lit.Value)
+		if err != nil || !sqlStatement.MatchString(format) {
+			return true
+		}
+		if strings.Count(format, "%")-2*strings.Count(format, "%%") > 0 {
+			issues = append(issues, s.issue(call, IssueSQLStringFormatting, CategoryBestPractices, SeverityHigh,
+				"SQL built with fmt.Sprintf is open to injection; use query placeholders"))
+		}
+		return true
+	})
+
+	return issues
+}
+
+// forEachFunc calls fn with the body of every function declaration and literal
+func forEachFunc(file *ast.File, fn func(body *ast.BlockStmt)) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				fn(n.Body)
+			}
+		case *ast.FuncLit:
+			fn(n.Body)
+		}
+		return true
+	})
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
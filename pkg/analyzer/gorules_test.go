@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rulesSource = `package sample
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+func save() error { return nil }
+
+func ignored(s string) int {
+	n, _ := strconv.Atoi(s)
+	_ = save()
+	save()
+	return n
+}
+
+func loops(paths []string) {
+	for _, p := range paths {
+		f, _ := os.Open(p)
+		defer f.Close()
+	}
+	for i := 0; i < 3; i++ {
+		func() {
+			defer fmt.Println(i)
+		}()
+	}
+}
+
+func leak(url string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return
+	}
+	fmt.Println(resp.StatusCode)
+}
+
+func closed(c *http.Client, req *http.Request) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+}
+
+func handler(ctx context.Context) {
+	go func() {
+		_ = context.Background()
+	}()
+}
+
+func noCtx() context.Context {
+	return context.Background()
+}
+
+func shadow() error {
+	err := save()
+	if err == nil {
+		err := save()
+		fmt.Println(err)
+	}
+	if err := save(); err != nil {
+		return err
+	}
+	return err
+}
+
+func query(table, id string) string {
+	safe := fmt.Sprintf("100%% of %s", table)
+	return safe + fmt.Sprintf("SELECT * FROM %s WHERE id = '%s'", table, id)
+}
+`
+
+func TestGoRules(t *testing.T) {
+	fset, file, err := parseGoFile("sample.go", []byte(rulesSource))
+	require.NoError(t, err)
+
+	fc := &FileContext{Go: checkGoSource("sample.go", fset, file)}
+
+	found := make(map[string][]int)
+	for _, rule := range goIssueRules() {
+		issues, metrics, err := rule.Check(context.Background(), fc)
+		require.NoError(t, err)
+		assert.Empty(t, metrics)
+		for _, issue := range issues {
+			assert.Equal(t, "sample.go", issue.File)
+			found[issue.Type] = append(found[issue.Type], issue.Line)
+		}
+	}
+
+	assert.Equal(t, []int{14, 15, 16, 22}, found[IssueIgnoredError])
+	assert.Equal(t, []int{23}, found[IssueDeferInLoop])
+	assert.Equal(t, []int{33}, found[IssueUnclosedBody])
+	assert.Equal(t, []int{50}, found[IssueContextBackground])
+	assert.Equal(t, []int{61}, found[IssueShadowedErr])
+	assert.Equal(t, []int{72}, found[IssueSQLStringFormatting])
+}
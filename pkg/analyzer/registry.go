@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds the language analyzers and rules used by a CodeAnalyzer.
+// Rules are keyed by language and can be enabled or disabled by name.
+type Registry struct {
+	mu        sync.RWMutex
+	languages []LanguageAnalyzer
+	rules     map[Language][]Rule
+	names     map[string]bool
+	disabled  map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		rules:    make(map[Language][]Rule),
+		names:    make(map[string]bool),
+		disabled: make(map[string]bool),
+	}
+}
+
+// RegisterLanguage adds a language analyzer. Analyzers are matched against
+// file names in registration order, so register catch-alls last.
+func (r *Registry) RegisterLanguage(language LanguageAnalyzer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.languages = append(r.languages, language)
+}
+
+func (r *Registry) RegisterRule(rule Rule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[rule.Name()] {
+		return fmt.Errorf("rule %q is already registered", rule.Name())
+	}
+
+	r.names[rule.Name()] = true
+	r.rules[rule.Language()] = append(r.rules[rule.Language()], rule)
+	return nil
+}
+
+func (r *Registry) Enable(name string) error {
+	return r.setEnabled(name, true)
+}
+
+func (r *Registry) Disable(name string) error {
+	return r.setEnabled(name, false)
+}
+
+func (r *Registry) setEnabled(name string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.names[name] {
+		return fmt.Errorf("unknown rule %q", name)
+	}
+
+	if enabled {
+		delete(r.disabled, name)
+	} else {
+		r.disabled[name] = true
+	}
+	return nil
+}
+
+func (r *Registry) Enabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.names[name] && !r.disabled[name]
+}
+
+// LanguageFor returns the first language analyzer matching the file name
+func (r *Registry) LanguageFor(filename string) (LanguageAnalyzer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, language := range r.languages {
+		if language.Match(filename) {
+			return language, true
+		}
+	}
+	return nil, false
+}
+
+// Rules returns the enabled rules for a language
+func (r *Registry) Rules(language Language) []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []Rule
+	for _, rule := range r.rules[language] {
+		if !r.disabled[rule.Name()] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// rulesFor returns the rules for a language after applying per-run options
+func (r *Registry) rulesFor(language Language, opts Options) []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []Rule
+	for _, rule := range r.rules[language] {
+		name := rule.Name()
+		enabled := !r.disabled[name] && !opts.DisabledRules[name]
+		if enabled || opts.EnabledRules[name] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// RuleNames returns the names of every registered rule, sorted
+func (r *Registry) RuleNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.names))
+	for name := range r.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noopCheck(context.Context, *FileContext) ([]Issue, []Metric, error) {
+	return nil, nil, nil
+}
+
+func TestRegistryRules(t *testing.T) {
+	registry := NewRegistry()
+
+	assert.NoError(t, registry.RegisterRule(NewRule("go/a", LanguageGo, noopCheck)))
+	assert.NoError(t, registry.RegisterRule(NewRule("go/b", LanguageGo, noopCheck)))
+	assert.NoError(t, registry.RegisterRule(NewRule("py/a", LanguagePython, noopCheck)))
+	assert.Error(t, registry.RegisterRule(NewRule("go/a", LanguageGo, noopCheck)))
+
+	assert.Len(t, registry.Rules(LanguageGo), 2)
+	assert.Equal(t, []string{"go/a", "go/b", "py/a"}, registry.RuleNames())
+
+	assert.NoError(t, registry.Disable("go/a"))
+	assert.False(t, registry.Enabled("go/a"))
+	assert.Len(t, registry.Rules(LanguageGo), 1)
+	assert.Equal(t, "go/b", registry.Rules(LanguageGo)[0].Name())
+
+	assert.NoError(t, registry.Enable("go/a"))
+	assert.Len(t, registry.Rules(LanguageGo), 2)
+
+	assert.Error(t, registry.Disable("missing"))
+}
+
+func TestRegistryLanguageFor(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterLanguage(&extensionLanguage{language: LanguagePython, extensions: []string{".py"}})
+	registry.RegisterLanguage(&extensionLanguage{language: LanguageGeneric})
+
+	language, ok := registry.LanguageFor("main.py")
+	assert.True(t, ok)
+	assert.Equal(t, LanguagePython, language.Language())
+
+	language, ok = registry.LanguageFor("README.md")
+	assert.True(t, ok)
+	assert.Equal(t, LanguageGeneric, language.Language())
+
+	_, ok = NewRegistry().LanguageFor("main.go")
+	assert.False(t, ok)
+}
@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"context"
+
+	"git-gud-bot/pkg/github"
+)
+
+// Language identifies the LanguageAnalyzer responsible for a file
+type Language string
+
+const (
+	LanguageGo         Language = "go"
+	LanguageJavaScript Language = "javascript"
+	LanguagePython     Language = "python"
+	LanguageGeneric    Language = "generic"
+)
+
+// FileContext is what rules get to inspect for one changed file. The
+// language analyzer fills in the fields its rules need.
+type FileContext struct {
+	// GitHub is the client for the host the PR lives on
+	GitHub *github.Client
+	PR     *github.PullRequest
+	File   github.File
+
+	// Content is the file at the head of the PR. Contents are only fetched
+	// when a rule that runs on the file needs them.
+	Content []byte
+	// BaseContent is the file before the PR, at the base commit. It is nil
+	// for added files.
+	BaseContent []byte
+
+	// Added holds the line numbers added by the patch. It is nil when the
+	// patch is unavailable, in which case every line counts as changed.
+	Added map[int]bool
+
+	// Go is the parsed file for Go rules; nil if it failed to parse
+	Go      *GoSource
+	GoError error
+}
+
+// Changed reports whether a line of the new file was touched by the PR
+func (fc *FileContext) Changed(line int) bool {
+	return fc.Added == nil || fc.Added[line]
+}
+
+// Options tune a single AnalyzeCode run, typically from a repository's own
+// configuration. The zero value runs every enabled rule on every file.
+type Options struct {
+	// EnabledRules turns on rules disabled in the registry
+	EnabledRules map[string]bool
+	// DisabledRules turns off rules for this run only
+	DisabledRules map[string]bool
+	// SeverityOverrides replaces the severity of issues by rule name
+	SeverityOverrides map[string]string
+	// Ignore skips files entirely, e.g. vendored or generated code
+	Ignore func(filename string) bool
+}
+
+// Rule is a single check for files of one language
+type Rule interface {
+	Name() string
+	Language() Language
+	Check(ctx context.Context, fc *FileContext) ([]Issue, []Metric, error)
+}
+
+// ContentRule is implemented by rules that can say whether they read a
+// file's Content and BaseContent. Rules that don't implement it are assumed
+// to need them.
+type ContentRule interface {
+	NeedsContent() bool
+}
+
+// LanguageAnalyzer decides which files belong to a language and prepares
+// their FileContext before the language's rules run. Load is only called
+// once the file's contents have been fetched.
+type LanguageAnalyzer interface {
+	Language() Language
+	Match(filename string) bool
+	Load(ctx context.Context, fc *FileContext) error
+}
+
+type CheckFunc func(ctx context.Context, fc *FileContext) ([]Issue, []Metric, error)
+
+type funcRule struct {
+	name         string
+	language     Language
+	check        CheckFunc
+	metadataOnly bool
+}
+
+// NewRule adapts a function to the Rule interface
+func NewRule(name string, language Language, check CheckFunc) Rule {
+	return &funcRule{
+		name:     name,
+		language: language,
+		check:    check,
+	}
+}
+
+// NewMetadataRule adapts a function that only looks at a file's metadata and
+// patch. Files whose rules are all metadata rules are never fetched.
+func NewMetadataRule(name string, language Language, check CheckFunc) Rule {
+	return &funcRule{
+		name:         name,
+		language:     language,
+		check:        check,
+		metadataOnly: true,
+	}
+}
+
+func (r *funcRule) Name() string {
+	return r.name
+}
+
+func (r *funcRule) Language() Language {
+	return r.language
+}
+
+func (r *funcRule) Check(ctx context.Context, fc *FileContext) ([]Issue, []Metric, error) {
+	return r.check(ctx, fc)
+}
+
+func (r *funcRule) NeedsContent() bool {
+	return !r.metadataOnly
+}
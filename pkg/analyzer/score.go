@@ -0,0 +1,218 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Score categories. Every issue counts against exactly one of them.
+const (
+	CategoryCodeQuality   = "code_quality"
+	CategoryPerformance   = "performance"
+	CategoryBestPractices = "best_practices"
+)
+
+// ScoringModel turns issues and metrics into the three 0-100 scores.
+//
+// Each category starts at 100. Issues in the category are summed by severity
+// weight and divided by the size of the change in units of LinesPerUnit
+// changed lines (at least one unit), so the same mistake costs less in a large
+// PR than in a small one. That density times PointsPerWeight is deducted, up to
+// MaxIssuePenalty. Code quality additionally loses points for every changed
+// function over the complexity or length thresholds, up to MaxMetricPenalty.
+type ScoringModel struct {
+	SeverityWeights  map[string]float64
+	DefaultWeight    float64
+	LinesPerUnit     int
+	PointsPerWeight  float64
+	MaxIssuePenalty  float64
+	MaxMetricPenalty float64
+
+	ComplexityThreshold float64
+	ComplexityPenalty   float64
+	LengthThreshold     float64
+	LengthPenalty       float64
+}
+
+func DefaultScoringModel() ScoringModel {
+	return ScoringModel{
+		SeverityWeights: map[string]float64{
+			SeverityLow:    1,
+			SeverityMedium: 3,
+			SeverityHigh:   8,
+		},
+		DefaultWeight:    1,
+		LinesPerUnit:     100,
+		PointsPerWeight:  4,
+		MaxIssuePenalty:  70,
+		MaxMetricPenalty: 30,
+
+		ComplexityThreshold: 10,
+		ComplexityPenalty:   3,
+		LengthThreshold:     60,
+		LengthPenalty:       2,
+	}
+}
+
+// Validate rejects models that can't produce meaningful scores
+func (m ScoringModel) Validate() error {
+	var errs []error
+	if m.LinesPerUnit <= 0 {
+		errs = append(errs, fmt.Errorf("lines per unit must be positive, got %d", m.LinesPerUnit))
+	}
+	if m.DefaultWeight < 0 || m.PointsPerWeight < 0 {
+		errs = append(errs, errors.New("weights and points per weight must not be negative"))
+	}
+	for severity, weight := range m.SeverityWeights {
+		if weight < 0 {
+			errs = append(errs, fmt.Errorf("weight for %s severity must not be negative", severity))
+		}
+	}
+	if m.MaxIssuePenalty < 0 || m.MaxMetricPenalty < 0 || m.ComplexityPenalty < 0 || m.LengthPenalty < 0 {
+		errs = append(errs, errors.New("penalties must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// ScoreBreakdown explains how a category's score was reached
+type ScoreBreakdown struct {
+	Category      string      `json:"category"`
+	Score         float64     `json:"score"`
+	ChangedLines  int         `json:"changed_lines"`
+	IssueWeight   float64     `json:"issue_weight"`
+	IssuePenalty  float64     `json:"issue_penalty"`
+	MetricPenalty float64     `json:"metric_penalty"`
+	Deductions    []Deduction `json:"deductions,omitempty"`
+}
+
+// Deduction is one line of a breakdown. The deductions of a category add up
+// to its penalties; where a cap applies, a final deduction with negative
+// points gives back what went over it.
+type Deduction struct {
+	Reason string  `json:"reason"`
+	Points float64 `json:"points"`
+}
+
+// Score fills in the analysis scores and breakdown. The model must be valid.
+func (m ScoringModel) Score(analysis *Analysis, changedLines int) {
+	breakdown := map[string]*ScoreBreakdown{
+		CategoryCodeQuality:   {Category: CategoryCodeQuality, ChangedLines: changedLines},
+		CategoryPerformance:   {Category: CategoryPerformance, ChangedLines: changedLines},
+		CategoryBestPractices: {Category: CategoryBestPractices, ChangedLines: changedLines},
+	}
+
+	units := math.Max(1, float64(changedLines)/float64(m.LinesPerUnit))
+
+	// Group issue weights by category and severity for readable deductions
+	type group struct {
+		count  int
+		weight float64
+	}
+	groups := make(map[string]map[string]*group)
+	for _, issue := range analysis.Issues {
+		category := issue.Category
+		if breakdown[category] == nil {
+			category = CategoryCodeQuality
+		}
+		weight, ok := m.SeverityWeights[issue.Severity]
+		if !ok {
+			weight = m.DefaultWeight
+		}
+
+		breakdown[category].IssueWeight += weight
+		if groups[category] == nil {
+			groups[category] = make(map[string]*group)
+		}
+		if groups[category][issue.Severity] == nil {
+			groups[category][issue.Severity] = &group{}
+		}
+		groups[category][issue.Severity].count++
+		groups[category][issue.Severity].weight += weight
+	}
+
+	for category, b := range breakdown {
+		severities := make([]string, 0, len(groups[category]))
+		for severity := range groups[category] {
+			severities = append(severities, severity)
+		}
+		sort.Strings(severities)
+
+		deducted := 0.0
+		for _, severity := range severities {
+			g := groups[category][severity]
+			points := round(g.weight / units * m.PointsPerWeight)
+			deducted += points
+			b.Deductions = append(b.Deductions, Deduction{
+				Reason: fmt.Sprintf("%d %s severity issue(s)", g.count, severity),
+				Points: points,
+			})
+		}
+		b.IssuePenalty = round(math.Min(m.MaxIssuePenalty, b.IssueWeight/units*m.PointsPerWeight))
+		if b.IssueWeight/units*m.PointsPerWeight > m.MaxIssuePenalty {
+			b.Deductions = append(b.Deductions, capDeduction("issue", m.MaxIssuePenalty, b.IssuePenalty-deducted))
+		}
+	}
+
+	quality := breakdown[CategoryCodeQuality]
+	for _, file := range sortedKeys(analysis.Metrics) {
+		for _, metric := range analysis.Metrics[file] {
+			if metric.Function == "" {
+				continue
+			}
+			switch {
+			case metric.Name == "cyclomatic_complexity" && metric.Value > m.ComplexityThreshold:
+				quality.MetricPenalty += m.ComplexityPenalty
+				quality.Deductions = append(quality.Deductions, Deduction{
+					Reason: fmt.Sprintf("%s in %s has complexity %.0f (threshold %.0f)", metric.Function, file, metric.Value, m.ComplexityThreshold),
+					Points: m.ComplexityPenalty,
+				})
+			case metric.Name == "function_length" && metric.Value > m.LengthThreshold:
+				quality.MetricPenalty += m.LengthPenalty
+				quality.Deductions = append(quality.Deductions, Deduction{
+					Reason: fmt.Sprintf("%s in %s is %.0f lines long (threshold %.0f)", metric.Function, file, metric.Value, m.LengthThreshold),
+					Points: m.LengthPenalty,
+				})
+			}
+		}
+	}
+	if quality.MetricPenalty > m.MaxMetricPenalty {
+		quality.Deductions = append(quality.Deductions, capDeduction("function", m.MaxMetricPenalty, m.MaxMetricPenalty-quality.MetricPenalty))
+		quality.MetricPenalty = m.MaxMetricPenalty
+	}
+
+	analysis.Breakdown = make([]ScoreBreakdown, 0, len(breakdown))
+	for _, category := range []string{CategoryCodeQuality, CategoryPerformance, CategoryBestPractices} {
+		b := breakdown[category]
+		b.Score = round(math.Max(0, 100-b.IssuePenalty-b.MetricPenalty))
+		analysis.Breakdown = append(analysis.Breakdown, *b)
+	}
+
+	analysis.CodeQuality = breakdown[CategoryCodeQuality].Score
+	analysis.Performance = breakdown[CategoryPerformance].Score
+	analysis.BestPractices = breakdown[CategoryBestPractices].Score
+}
+
+// capDeduction gives back the points of a kind of penalty that went over its
+// cap; adjustment is negative
+func capDeduction(kind string, limit, adjustment float64) Deduction {
+	return Deduction{
+		Reason: fmt.Sprintf("%s penalties are capped at %.0f points", kind, limit),
+		Points: round(adjustment),
+	}
+}
+
+// round keeps scores to one decimal place
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func sortedKeys(m map[string][]Metric) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreWithoutFindings(t *testing.T) {
+	analysis := &Analysis{Metrics: map[string][]Metric{}}
+	DefaultScoringModel().Score(analysis, 40)
+
+	assert.Equal(t, 100.0, analysis.CodeQuality)
+	assert.Equal(t, 100.0, analysis.Performance)
+	assert.Equal(t, 100.0, analysis.BestPractices)
+	require.Len(t, analysis.Breakdown, 3)
+	assert.Empty(t, analysis.Breakdown[0].Deductions)
+}
+
+func TestScoreIssueDensity(t *testing.T) {
+	issues := []Issue{
+		{Category: CategoryCodeQuality, Severity: SeverityMedium},
+		{Category: CategoryPerformance, Severity: SeverityHigh},
+		{Category: "", Severity: "unknown"},
+	}
+
+	small := &Analysis{Issues: issues}
+	DefaultScoringModel().Score(small, 50)
+
+	// Below 100 changed lines counts as one unit: (3+1)*4 and 8*4
+	assert.Equal(t, 84.0, small.CodeQuality)
+	assert.Equal(t, 68.0, small.Performance)
+	assert.Equal(t, 100.0, small.BestPractices)
+	assert.Equal(t, 4.0, small.Breakdown[0].IssueWeight)
+
+	large := &Analysis{Issues: issues}
+	DefaultScoringModel().Score(large, 400)
+
+	assert.Equal(t, 96.0, large.CodeQuality)
+	assert.Equal(t, 92.0, large.Performance)
+}
+
+func TestScoreMetricThresholds(t *testing.T) {
+	analysis := &Analysis{
+		Metrics: map[string][]Metric{
+			"main.go": {
+				{Name: "cyclomatic_complexity", Value: 30},
+				{Name: "cyclomatic_complexity", Function: "handle", Value: 14},
+				{Name: "function_length", Function: "handle", Value: 90},
+				{Name: "function_length", Function: "small", Value: 10},
+			},
+		},
+	}
+	DefaultScoringModel().Score(analysis, 100)
+
+	assert.Equal(t, 95.0, analysis.CodeQuality)
+	quality := analysis.Breakdown[0]
+	assert.Equal(t, CategoryCodeQuality, quality.Category)
+	assert.Equal(t, 5.0, quality.MetricPenalty)
+	assert.Len(t, quality.Deductions, 2)
+}
+
+func TestScorePenaltyCap(t *testing.T) {
+	var issues []Issue
+	for i := 0; i < 20; i++ {
+		issues = append(issues, Issue{Category: CategoryBestPractices, Severity: SeverityHigh})
+	}
+	analysis := &Analysis{Issues: issues}
+	DefaultScoringModel().Score(analysis, 10)
+
+	assert.Equal(t, 30.0, analysis.BestPractices)
+
+	// The deductions shown add up to the capped penalty
+	practices := analysis.Breakdown[2]
+	require.Len(t, practices.Deductions, 2)
+	assert.Equal(t, 640.0, practices.Deductions[0].Points)
+	assert.Equal(t, -570.0, practices.Deductions[1].Points)
+	assert.Equal(t, practices.IssuePenalty, practices.Deductions[0].Points+practices.Deductions[1].Points)
+}
+
+func TestScoreMetricPenaltyCap(t *testing.T) {
+	var metrics []Metric
+	for i := 0; i < 12; i++ {
+		metrics = append(metrics, Metric{Name: "cyclomatic_complexity", Function: fmt.Sprintf("f%d", i), Value: 20})
+	}
+	analysis := &Analysis{Metrics: map[string][]Metric{"main.go": metrics}}
+	DefaultScoringModel().Score(analysis, 100)
+
+	quality := analysis.Breakdown[0]
+	assert.Equal(t, 30.0, quality.MetricPenalty)
+	total := 0.0
+	for _, deduction := range quality.Deductions {
+		total += deduction.Points
+	}
+	assert.Equal(t, 30.0, total)
+}
+
+func TestScoringModelValidate(t *testing.T) {
+	assert.NoError(t, DefaultScoringModel().Validate())
+
+	m := DefaultScoringModel()
+	m.LinesPerUnit = 0
+	assert.ErrorContains(t, m.Validate(), "lines per unit")
+
+	a := NewCodeAnalyzer(nil)
+	assert.Error(t, a.SetScoringModel(m))
+	assert.NoError(t, a.SetScoringModel(DefaultScoringModel()))
+}
@@ -0,0 +1,297 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHub rejects app JWTs valid for more than ten minutes
+	appJWTLifetime = 9 * time.Minute
+	// Backdate iat to tolerate clock drift between us and GitHub
+	appJWTClockSkew = 60 * time.Second
+	// Installation tokens last an hour; refresh well before they expire
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// AppTokenSource authenticates as a GitHub App installation. It looks up the
+// installation for each repository, exchanges a signed app JWT for an
+// installation access token and caches both until shortly before expiry.
+//
+// Requests to GitHub are made without holding the lock, and concurrent
+// callers for the same repository share a single request. Cached entries are
+// dropped when GitHub answers 401 or 404, e.g. after the app was reinstalled.
+type AppTokenSource struct {
+	appID      int64
+	key        *rsa.PrivateKey
+	baseURL    string
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu            sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]installationToken
+	inflight      map[string]*tokenCall
+}
+
+// tokenCall is a token request that other callers for the same repository
+// wait on
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewAppTokenSource(appID int64, privateKeyPEM []byte, baseURL string) (*AppTokenSource, error) {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppTokenSource{
+		appID:         appID,
+		key:           key,
+		baseURL:       baseURL,
+		httpClient:    &http.Client{Timeout: defaultTimeout},
+		now:           time.Now,
+		installations: make(map[string]int64),
+		tokens:        make(map[int64]installationToken),
+		inflight:      make(map[string]*tokenCall),
+	}, nil
+}
+
+// Token returns an installation access token for the app installation that
+// covers owner/repo
+func (s *AppTokenSource) Token(ctx context.Context, owner, repo string) (string, error) {
+	key := owner + "/" + repo
+
+	s.mu.Lock()
+	if id, ok := s.installations[key]; ok {
+		if token, ok := s.tokens[id]; ok && s.now().Add(tokenRefreshMargin).Before(token.ExpiresAt) {
+			s.mu.Unlock()
+			return token.Token, nil
+		}
+	}
+	call, waiting := s.inflight[key]
+	if !waiting {
+		call = &tokenCall{done: make(chan struct{})}
+		s.inflight[key] = call
+	}
+	s.mu.Unlock()
+
+	if waiting {
+		select {
+		case <-call.done:
+			return call.token, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	call.token, call.err = s.fetchToken(ctx, owner, repo)
+
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(call.done)
+
+	return call.token, call.err
+}
+
+// Identity names the installation covering owner/repo, which is what GitHub
+// applies rate limits to. It is empty until Token has found the installation.
+func (s *AppTokenSource) Identity(owner, repo string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.installations[owner+"/"+repo]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("app/%d/installation/%d", s.appID, id)
+}
+
+// Invalidate drops what is cached for owner/repo, so the next Token call
+// looks up the installation and creates a token again
+func (s *AppTokenSource) Invalidate(owner, repo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := owner + "/" + repo
+	if id, ok := s.installations[key]; ok {
+		delete(s.tokens, id)
+		delete(s.installations, key)
+	}
+}
+
+// fetchToken creates a token for the repository's installation. A cached
+// installation that GitHub no longer knows is looked up again once.
+func (s *AppTokenSource) fetchToken(ctx context.Context, owner, repo string) (string, error) {
+	id, cached, err := s.installationID(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.createToken(ctx, id)
+	if cached && staleCredentials(err) {
+		s.Invalidate(owner, repo)
+		if id, _, err = s.installationID(ctx, owner, repo); err != nil {
+			return "", err
+		}
+		token, err = s.createToken(ctx, id)
+	}
+	if err != nil {
+		if staleCredentials(err) {
+			s.Invalidate(owner, repo)
+		}
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+
+	s.mu.Lock()
+	s.tokens[id] = token
+	s.mu.Unlock()
+
+	return token.Token, nil
+}
+
+// installationID returns the installation covering owner/repo and whether it
+// came from the cache
+func (s *AppTokenSource) installationID(ctx context.Context, owner, repo string) (int64, bool, error) {
+	key := owner + "/" + repo
+
+	s.mu.Lock()
+	id, ok := s.installations[key]
+	s.mu.Unlock()
+	if ok {
+		return id, true, nil
+	}
+
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/installation", s.baseURL, owner, repo)
+	if err := s.appRequest(ctx, "GET", url, http.StatusOK, &installation); err != nil {
+		return 0, false, fmt.Errorf("failed to find app installation for %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	s.installations[key] = installation.ID
+	s.mu.Unlock()
+
+	return installation.ID, false, nil
+}
+
+func (s *AppTokenSource) createToken(ctx context.Context, id int64) (installationToken, error) {
+	var token installationToken
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.baseURL, id)
+	err := s.appRequest(ctx, "POST", url, http.StatusCreated, &token)
+	return token, err
+}
+
+// staleCredentials reports whether GitHub rejected a cached installation or
+// token, as it does once the app has been uninstalled
+func staleCredentials(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusUnauthorized)
+}
+
+// appRequest calls an endpoint that authenticates as the app itself
+func (s *AppTokenSource) appRequest(ctx context.Context, method, url string, want int, out interface{}) error {
+	jwt, err := s.JWT()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		return newAPIError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// JWT returns a short-lived RS256 token identifying the app
+func (s *AppTokenSource) JWT() (string, error) {
+	now := s.now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app JWT: %w", err)
+	}
+
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey accepts the PKCS#1 keys GitHub issues as well as PKCS#8
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("app private key is not an RSA key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,223 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, keyPEM := newAppKey(t)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lookups, exchanges := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifyAppJWT(t, &key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/octo/app/installation":
+			lookups++
+			fmt.Fprint(w, `{"id": 42}`)
+		case r.Method == "POST" && r.URL.Path == "/app/installations/42/access_tokens":
+			exchanges++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, exchanges, now.Add(time.Hour).Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewAppTokenSource(7, keyPEM, server.URL)
+	require.NoError(t, err)
+	source.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	token, err := source.Token(ctx, "octo", "app")
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_1", token)
+
+	// Cached until close to expiry
+	now = now.Add(50 * time.Minute)
+	token, err = source.Token(ctx, "octo", "app")
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_1", token)
+
+	now = now.Add(6 * time.Minute)
+	token, err = source.Token(ctx, "octo", "app")
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_2", token)
+	assert.Equal(t, 1, lookups)
+	assert.Equal(t, 2, exchanges)
+
+	// Rotated tokens share the installation's identity
+	assert.Equal(t, "app/7/installation/42", source.Identity("octo", "app"))
+	assert.Empty(t, source.Identity("octo", "other"))
+
+	_, err = source.Token(ctx, "octo", "missing")
+	assert.Error(t, err, "the app is not installed on the repository")
+}
+
+func TestAppTokenSourceConcurrency(t *testing.T) {
+	_, keyPEM := newAppKey(t)
+
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octo/slow/installation":
+			<-release
+			fmt.Fprint(w, `{"id": 1}`)
+		case strings.HasSuffix(r.URL.Path, "/installation"):
+			lookups.Add(1)
+			fmt.Fprint(w, `{"id": 2}`)
+		default:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	source, err := NewAppTokenSource(7, keyPEM, server.URL)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	go source.Token(ctx, "octo", "slow")
+
+	// A slow repository doesn't hold up the others, and callers for the same
+	// repository share one lookup
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(ctx, "octo", "app")
+			assert.NoError(t, err)
+			assert.Equal(t, "ghs", token)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Token blocked behind a request for another repository")
+	}
+	assert.Equal(t, int32(1), lookups.Load())
+}
+
+func TestAppTokenSourceReinstalled(t *testing.T) {
+	_, keyPEM := newAppKey(t)
+
+	installation := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octo/app/installation":
+			fmt.Fprintf(w, `{"id": %d}`, installation)
+		case r.URL.Path == fmt.Sprintf("/app/installations/%d/access_tokens", installation):
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, installation, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewAppTokenSource(7, keyPEM, server.URL)
+	require.NoError(t, err)
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	token, err := source.Token(ctx, "octo", "app")
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_1", token)
+
+	// The cached installation is unknown by the time the token is renewed
+	installation = 2
+	now = now.Add(time.Hour)
+
+	token, err = source.Token(ctx, "octo", "app")
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_2", token)
+}
+
+func TestClientInvalidatesRejectedTokens(t *testing.T) {
+	invalidated := &recordingSource{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message": "Bad credentials"}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithAuth(invalidated, WithEndpoints(Endpoints{BaseURL: server.URL}))
+	_, err := client.GetPullRequest(context.Background(), "octo", "app", 1)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"octo/app"}, invalidated.repos)
+}
+
+type recordingSource struct {
+	repos []string
+}
+
+func (s *recordingSource) Token(context.Context, string, string) (string, error) {
+	return "revoked", nil
+}
+
+func (s *recordingSource) Invalidate(owner, repo string) {
+	s.repos = append(s.repos, owner+"/"+repo)
+}
+
+func newAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func verifyAppJWT(t *testing.T, key *rsa.PublicKey, token string) {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3, "malformed JWT %q", token)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature), "JWT signature does not verify")
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims struct {
+		IAT int64 `json:"iat"`
+		EXP int64 `json:"exp"`
+		ISS int64 `json:"iss"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, int64(7), claims.ISS)
+	assert.LessOrEqual(t, claims.EXP-claims.IAT, int64(600))
+}
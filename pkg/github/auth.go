@@ -0,0 +1,58 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// TokenSource provides the token that authenticates requests for a repository
+type TokenSource interface {
+	Token(ctx context.Context, owner, repo string) (string, error)
+}
+
+// invalidator is implemented by token sources that cache tokens, so a token
+// GitHub rejects is not used again
+type invalidator interface {
+	Invalidate(owner, repo string)
+}
+
+// identifier is implemented by token sources whose tokens rotate, so rate
+// limits and cached responses follow what a token belongs to rather than the
+// token itself. An empty identity means none is known.
+type identifier interface {
+	Identity(owner, repo string) string
+}
+
+type identityKey struct{}
+
+// withIdentity tells the transport who a request is made as
+func withIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// requestIdentity returns the identity a request is made as, falling back to
+// its Authorization header for requests that did not come from a Client
+func requestIdentity(ctx context.Context, authorization string) string {
+	if identity, ok := ctx.Value(identityKey{}).(string); ok && identity != "" {
+		return identity
+	}
+	return tokenIdentity(authorization)
+}
+
+// tokenIdentity identifies a token without keeping the secret itself around
+func tokenIdentity(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token/" + hex.EncodeToString(sum[:8])
+}
+
+// StaticToken authenticates every request with the same token, such as a
+// personal access token
+type StaticToken string
+
+func (t StaticToken) Token(_ context.Context, _, _ string) (string, error) {
+	return string(t), nil
+}
@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Check run statuses and conclusions
+const (
+	CheckStatusInProgress = "in_progress"
+	CheckStatusCompleted  = "completed"
+
+	CheckConclusionSuccess        = "success"
+	CheckConclusionNeutral        = "neutral"
+	CheckConclusionFailure        = "failure"
+	CheckConclusionActionRequired = "action_required"
+)
+
+// Annotation levels for check run annotations
+const (
+	AnnotationNotice  = "notice"
+	AnnotationWarning = "warning"
+	AnnotationFailure = "failure"
+)
+
+// MaxAnnotationsPerRequest is the most annotations GitHub accepts in a single
+// create or update call; further annotations are appended by more updates
+const MaxAnnotationsPerRequest = 50
+
+type CheckRun struct {
+	ID          int64           `json:"id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	HeadSHA     string          `json:"head_sha,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Conclusion  string          `json:"conclusion,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	ExternalID  string          `json:"external_id,omitempty"`
+	DetailsURL  string          `json:"details_url,omitempty"`
+	HTMLURL     string          `json:"html_url,omitempty"`
+	Output      *CheckRunOutput `json:"output,omitempty"`
+}
+
+type CheckRunOutput struct {
+	Title       string            `json:"title"`
+	Summary     string            `json:"summary"`
+	Text        string            `json:"text,omitempty"`
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+	// AnnotationsCount is how many annotations the run has; GitHub reports it
+	// but ignores it in requests
+	AnnotationsCount int `json:"annotations_count,omitempty"`
+}
+
+type CheckAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+}
+
+func (c *Client) CreateCheckRun(ctx context.Context, owner, repo string, run *CheckRun) (*CheckRun, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs", c.baseURL, owner, repo)
+
+	var created CheckRun
+	if err := c.do(ctx, owner, repo, "POST", url, run, http.StatusCreated, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// UpdateCheckRun changes a check run. Annotations in the output are appended
+// to those already on the run.
+func (c *Client) UpdateCheckRun(ctx context.Context, owner, repo string, id int64, run *CheckRun) (*CheckRun, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", c.baseURL, owner, repo, id)
+
+	var updated CheckRun
+	if err := c.do(ctx, owner, repo, "PATCH", url, run, http.StatusOK, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// ListCheckRuns returns the check runs called name at ref, newest first. Only
+// the first page is read, which holds far more runs of one name than a
+// commit normally gets.
+func (c *Client) ListCheckRuns(ctx context.Context, owner, repo, ref, name string) ([]CheckRun, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-runs?check_name=%s&per_page=%d",
+		c.baseURL, owner, repo, url.PathEscape(ref), url.QueryEscape(name), perPage)
+
+	var list struct {
+		CheckRuns []CheckRun `json:"check_runs"`
+	}
+	if err := c.do(ctx, owner, repo, "GET", endpoint, nil, http.StatusOK, &list); err != nil {
+		return nil, err
+	}
+
+	return list.CheckRuns, nil
+}
@@ -1,72 +1,142 @@
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 )
 
 type Client struct {
 	httpClient *http.Client
-	token      string
+	transport  *Transport
+	auth       TokenSource
 	baseURL    string
+	uploadURL  string
+	webURL     string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithEndpoints points the client at another GitHub instance, such as a
+// GitHub Enterprise Server
+func WithEndpoints(endpoints Endpoints) Option {
+	return func(c *Client) {
+		c.baseURL = endpoints.BaseURL
+		c.uploadURL = endpoints.UploadURL
+		c.webURL = endpoints.WebURL
+	}
 }
 
 type PullRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"body"`
-	State       string `json:"state"`
-	Head        struct {
-		SHA string `json:"sha"`
-	} `json:"head"`
-	Files []File `json:"files"`
+	Number       int    `json:"number"`
+	Title        string `json:"title"`
+	Description  string `json:"body"`
+	State        string `json:"state"`
+	HTMLURL      string `json:"html_url"`
+	Head         Branch `json:"head"`
+	Base         Branch `json:"base"`
+	ChangedFiles int    `json:"changed_files"`
+	Files        []File `json:"files"`
+	// FilesTruncated is set when Files holds fewer than ChangedFiles because
+	// GitHub lists at most MaxPullRequestFiles files
+	FilesTruncated bool `json:"-"`
+}
+
+type Branch struct {
+	Ref  string     `json:"ref"`
+	SHA  string     `json:"sha"`
+	Repo Repository `json:"repo"`
 }
 
 type File struct {
-	Name        string `json:"filename"`
-	Status      string `json:"status"`
-	Additions   int    `json:"additions"`
-	Deletions   int    `json:"deletions"`
-	Changes     int    `json:"changes"`
-	ContentsURL string `json:"contents_url"`
-	PatchURL    string `json:"patch_url"`
+	Name   string `json:"filename"`
+	Status string `json:"status"`
+	// PreviousName is set for renamed files
+	PreviousName string `json:"previous_filename,omitempty"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+	Changes      int    `json:"changes"`
+	ContentsURL  string `json:"contents_url"`
+	PatchURL     string `json:"patch_url"`
+	Patch        string `json:"patch"`
 }
 
-func NewClient(token string) *Client {
-	return &Client{
-		httpClient: &http.Client{},
-		token:      token,
-		baseURL:    "https://api.github.com",
-	}
+// NewClient authenticates with a single token, such as a personal access token
+func NewClient(token string, opts ...Option) *Client {
+	return NewClientWithAuth(StaticToken(token), opts...)
 }
 
-func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, repo, number)
+// NewAppClient authenticates as a GitHub App, using the installation token of
+// whichever installation covers the repository of each call
+func NewAppClient(appID int64, privateKeyPEM []byte, opts ...Option) (*Client, error) {
+	client := NewClientWithAuth(nil, opts...)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	auth, err := NewAppTokenSource(appID, privateKeyPEM, client.baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	client.auth = auth
 
-	c.setHeaders(req)
+	return client, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+func NewClientWithAuth(auth TokenSource, opts ...Option) *Client {
+	transport := NewTransport()
+	client := &Client{
+		httpClient: &http.Client{Transport: transport, Timeout: defaultTimeout},
+		transport:  transport,
+		auth:       auth,
+	}
+
+	WithEndpoints(DotComEndpoints())(client)
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+// Endpoints returns the GitHub instance the client talks to
+func (c *Client) Endpoints() Endpoints {
+	return Endpoints{
+		BaseURL:   c.baseURL,
+		UploadURL: c.uploadURL,
+		WebURL:    c.webURL,
+	}
+}
+
+// RateLimit returns the rate-limit state GitHub last reported for the token
+// used with owner/repo. ok is false until a request has been made.
+func (c *Client) RateLimit(ctx context.Context, owner, repo string) (limit RateLimit, ok bool, err error) {
+	token, err := c.auth.Token(ctx, owner, repo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return RateLimit{}, false, err
 	}
-	defer resp.Body.Close()
+	limit, ok = c.transport.RateLimit(c.identity(owner, repo, token))
+	return limit, ok, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error: %s, status: %d", string(body), resp.StatusCode)
+// identity is what the transport keys rate limits and cached responses by
+// for requests made with token on behalf of owner/repo
+func (c *Client) identity(owner, repo, token string) string {
+	if id, ok := c.auth.(identifier); ok {
+		if identity := id.Identity(owner, repo); identity != "" {
+			return identity
+		}
 	}
+	return tokenIdentity("Bearer " + token)
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, repo, number)
 
 	var pr PullRequest
-	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.do(ctx, owner, repo, "GET", url, nil, http.StatusOK, &pr); err != nil {
+		return nil, err
 	}
 
 	// Get PR files
@@ -75,77 +145,147 @@ func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number
 		return nil, fmt.Errorf("failed to get PR files: %w", err)
 	}
 	pr.Files = files
+	pr.FilesTruncated = len(files) < pr.ChangedFiles
 
 	return &pr, nil
 }
 
+// MaxPullRequestFiles is the most files GitHub will list for a pull request
+const MaxPullRequestFiles = 3000
+
+// GetPullRequestFiles lists every file of the pull request, up to
+// MaxPullRequestFiles
 func (c *Client) GetPullRequestFiles(ctx context.Context, owner, repo string, number int) ([]File, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/files", c.baseURL, owner, repo, number)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	return listAll[File](ctx, c, owner, repo, url, MaxPullRequestFiles)
+}
 
-	c.setHeaders(req)
+func (c *Client) CreateReviewComment(ctx context.Context, owner, repo string, number int, comment *ReviewComment) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", c.baseURL, owner, repo, number)
 
-	resp, err := c.httpClient.Do(req)
+	return c.do(ctx, owner, repo, "POST", url, comment, http.StatusCreated, nil)
+}
+
+type ReviewComment struct {
+	Body     string `json:"body"`
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	CommitID string `json:"commit_id"`
+}
+
+type IssueComment struct {
+	Body string `json:"body"`
+}
+
+// CreateIssueComment posts a comment on the pull request conversation rather
+// than on a line of the diff
+func (c *Client) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, owner, repo, number)
+
+	return c.do(ctx, owner, repo, "POST", url, IssueComment{Body: body}, http.StatusCreated, nil)
+}
+
+// Review events accepted by the Pull Request Reviews API
+const (
+	ReviewEventComment        = "COMMENT"
+	ReviewEventApprove        = "APPROVE"
+	ReviewEventRequestChanges = "REQUEST_CHANGES"
+)
+
+// PullRequestReview submits a summary body and any number of inline comments
+// as a single review, so the PR receives one notification
+type PullRequestReview struct {
+	ID       int64                `json:"id,omitempty"`
+	CommitID string               `json:"commit_id,omitempty"`
+	Body     string               `json:"body"`
+	Event    string               `json:"event"`
+	Comments []DraftReviewComment `json:"comments,omitempty"`
+}
+
+type DraftReviewComment struct {
+	Path     string `json:"path"`
+	Body     string `json:"body"`
+	Line     int    `json:"line,omitempty"`
+	Side     string `json:"side,omitempty"`
+	Position int    `json:"position,omitempty"`
+}
+
+func (c *Client) CreatePullRequestReview(ctx context.Context, owner, repo string, number int, review *PullRequestReview) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", c.baseURL, owner, repo, number)
+
+	return c.do(ctx, owner, repo, "POST", url, review, http.StatusOK, nil)
+}
+
+// ListPullRequestReviews returns every review submitted on the pull request,
+// oldest first
+func (c *Client) ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]PullRequestReview, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", c.baseURL, owner, repo, number)
+
+	return listAll[PullRequestReview](ctx, c, owner, repo, url, 0)
+}
+
+// do sends a request for owner/repo with an optional JSON body and decodes the
+// response into out when it is not nil. Any status other than want becomes an
+// *APIError.
+func (c *Client) do(ctx context.Context, owner, repo, method, url string, body interface{}, want int, out interface{}) error {
+	resp, err := c.send(ctx, owner, repo, method, url, body, want)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error: %s, status: %d", string(body), resp.StatusCode)
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
 	}
 
-	var files []File
-	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return files, nil
+	return nil
 }
 
-func (c *Client) CreateReviewComment(ctx context.Context, owner, repo string, number int, comment *ReviewComment) error {
-	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", c.baseURL, owner, repo, number)
+// send performs the request and returns the response when its status is want.
+// The caller must close the body.
+func (c *Client) send(ctx context.Context, owner, repo, method, url string, body interface{}, want int) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
 
-	jsonBody, err := json.Marshal(comment)
+	token, err := c.auth.Token(ctx, owner, repo)
 	if err != nil {
-		return fmt.Errorf("failed to marshal comment: %w", err)
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
+	reqCtx := withIdentity(ctx, c.identity(owner, repo, token))
+	req, err := http.NewRequestWithContext(reqCtx, method, url, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-
-	c.setHeaders(req)
+	setHeaders(req, token)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		responseBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API error: %s, status: %d", string(responseBody), resp.StatusCode)
+	if resp.StatusCode != want {
+		defer resp.Body.Close()
+		if inv, ok := c.auth.(invalidator); ok && resp.StatusCode == http.StatusUnauthorized {
+			inv.Invalidate(owner, repo)
+		}
+		return nil, newAPIError(resp)
 	}
 
-	return nil
-}
-
-type ReviewComment struct {
-	Body     string `json:"body"`
-	Path     string `json:"path"`
-	Position int    `json:"position"`
-	CommitID string `json:"commit_id"`
+	return resp, nil
 }
 
-func (c *Client) setHeaders(req *http.Request) {
-	req.Header.Set("Authorization", "Bearer "+c.token)
+func setHeaders(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("Content-Type", "application/json")
 }
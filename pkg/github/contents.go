@@ -0,0 +1,81 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type fileContent struct {
+	Type     string `json:"type"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+	SHA      string `json:"sha"`
+	Size     int64  `json:"size"`
+}
+
+type blob struct {
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// GetFileContents returns the raw contents of a file at the given ref. Files
+// over 1 MB, which the contents API returns without content, are fetched
+// through the Git blob API.
+func (c *Client) GetFileContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", c.baseURL, owner, repo, escapePath(path), url.QueryEscape(ref))
+
+	var content fileContent
+	if err := c.do(ctx, owner, repo, "GET", endpoint, nil, http.StatusOK, &content); err != nil {
+		return nil, err
+	}
+
+	if content.Type != "file" {
+		return nil, fmt.Errorf("%s is a %s, not a file", path, content.Type)
+	}
+	if content.Encoding == "none" || (content.Content == "" && content.Size > 0) {
+		return c.getBlob(ctx, owner, repo, content.SHA)
+	}
+
+	return decodeContent(path, content.Encoding, content.Content)
+}
+
+// getBlob fetches a file by its blob SHA, which works for files up to 100 MB
+func (c *Client) getBlob(ctx context.Context, owner, repo, sha string) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/git/blobs/%s", c.baseURL, owner, repo, url.PathEscape(sha))
+
+	var b blob
+	if err := c.do(ctx, owner, repo, "GET", endpoint, nil, http.StatusOK, &b); err != nil {
+		return nil, err
+	}
+
+	return decodeContent(sha, b.Encoding, b.Content)
+}
+
+func decodeContent(name, encoding, content string) ([]byte, error) {
+	switch encoding {
+	case "base64":
+		// GitHub wraps base64 content at 60 characters
+		data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode content: %w", err)
+		}
+		return data, nil
+	case "utf-8":
+		return []byte(content), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q for %s", encoding, name)
+	}
+}
+
+// escapePath escapes each segment of a repository file path
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,153 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type LineKind string
+
+const (
+	LineContext LineKind = "context"
+	LineAdded   LineKind = "added"
+	LineRemoved LineKind = "removed"
+)
+
+// DiffLine is a single line of a hunk. OldLine is zero for added lines and
+// NewLine is zero for removed lines.
+type DiffLine struct {
+	Kind     LineKind
+	Content  string
+	OldLine  int
+	NewLine  int
+	Position int
+}
+
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string
+	Lines    []DiffLine
+}
+
+// Patch is a parsed unified diff for a single file, as returned in the patch
+// field of the pull request files API
+type Patch struct {
+	Hunks     []Hunk
+	positions map[int]int
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParsePatch parses a unified diff. Positions follow the review comments API:
+// the line below the first hunk header is position 1 and counting continues
+// through later hunk headers.
+func ParsePatch(patch string) (*Patch, error) {
+	p := &Patch{positions: make(map[int]int)}
+	if patch == "" {
+		return p, nil
+	}
+
+	var hunk *Hunk
+	oldLine, newLine, position := 0, 0, 0
+
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		if strings.HasPrefix(text, "@@") {
+			m := hunkHeader.FindStringSubmatch(text)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", text)
+			}
+			if hunk != nil {
+				p.Hunks = append(p.Hunks, *hunk)
+				position++
+			}
+			hunk = &Hunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Section:  m[5],
+			}
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			continue
+		}
+
+		if hunk == nil {
+			return nil, fmt.Errorf("diff line before first hunk header: %q", text)
+		}
+
+		position++
+		line := DiffLine{Position: position}
+
+		switch {
+		case strings.HasPrefix(text, "+"):
+			line.Kind, line.Content, line.NewLine = LineAdded, text[1:], newLine
+			newLine++
+		case strings.HasPrefix(text, "-"):
+			line.Kind, line.Content, line.OldLine = LineRemoved, text[1:], oldLine
+			oldLine++
+		case strings.HasPrefix(text, `\`):
+			// "\ No newline at end of file" takes a position but no line
+			continue
+		default:
+			line.Kind, line.Content = LineContext, strings.TrimPrefix(text, " ")
+			line.OldLine, line.NewLine = oldLine, newLine
+			oldLine++
+			newLine++
+		}
+
+		if line.NewLine > 0 {
+			p.positions[line.NewLine] = line.Position
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read patch: %w", err)
+	}
+
+	if hunk != nil {
+		p.Hunks = append(p.Hunks, *hunk)
+	}
+
+	return p, nil
+}
+
+// Position returns the diff position of a line in the new version of the
+// file, and false if the line is not part of the diff
+func (p *Patch) Position(newLine int) (int, bool) {
+	position, ok := p.positions[newLine]
+	return position, ok
+}
+
+// AddedLines returns the new-file line numbers added by the patch
+func (p *Patch) AddedLines() map[int]bool {
+	added := make(map[int]bool)
+	for _, hunk := range p.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == LineAdded {
+				added[line.NewLine] = true
+			}
+		}
+	}
+	return added
+}
+
+func atoiDefault(s string, defaultValue int) int {
+	if s == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const samplePatch = `@@ -1,4 +1,5 @@ package main
+ import "fmt"
+-func old() {}
++func added() {}
++func another() {}
+ 
+ func main() {
+@@ -20,2 +21,3 @@ func main() {
+ 	fmt.Println("a")
++	fmt.Println("b")
+ }
+\ No newline at end of file`
+
+func TestParsePatch(t *testing.T) {
+	patch, err := ParsePatch(samplePatch)
+	require.NoError(t, err)
+	require.Len(t, patch.Hunks, 2)
+
+	first := patch.Hunks[0]
+	assert.Equal(t, 1, first.OldStart)
+	assert.Equal(t, 4, first.OldLines)
+	assert.Equal(t, 5, first.NewLines)
+	assert.Equal(t, "package main", first.Section)
+	assert.Equal(t, DiffLine{Kind: LineRemoved, Content: "func old() {}", OldLine: 2, Position: 2}, first.Lines[1])
+	assert.Equal(t, DiffLine{Kind: LineAdded, Content: "func added() {}", NewLine: 2, Position: 3}, first.Lines[2])
+
+	second := patch.Hunks[1]
+	assert.Equal(t, 21, second.NewStart)
+	assert.Len(t, second.Lines, 3)
+	assert.Equal(t, 22, second.Lines[1].NewLine)
+}
+
+func TestPatchPosition(t *testing.T) {
+	patch, err := ParsePatch(samplePatch)
+	require.NoError(t, err)
+
+	// The second hunk header occupies position 7
+	for line, want := range map[int]int{1: 1, 2: 3, 3: 4, 5: 6, 21: 8, 22: 9, 23: 10} {
+		position, ok := patch.Position(line)
+		assert.True(t, ok, "line %d", line)
+		assert.Equal(t, want, position, "line %d", line)
+	}
+
+	_, ok := patch.Position(10)
+	assert.False(t, ok)
+
+	assert.Equal(t, map[int]bool{2: true, 3: true, 22: true}, patch.AddedLines())
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	_, err := ParsePatch("+orphan line")
+	assert.Error(t, err)
+
+	_, err = ParsePatch("@@ broken @@")
+	assert.Error(t, err)
+
+	patch, err := ParsePatch("")
+	assert.NoError(t, err)
+	assert.Empty(t, patch.Hunks)
+}
@@ -0,0 +1,34 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIError is returned when GitHub responds with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RateLimited is set for primary and secondary rate-limit responses that
+	// outlasted the transport's retries
+	RateLimited bool
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{
+		StatusCode:  resp.StatusCode,
+		Body:        string(body),
+		RateLimited: isRateLimited(resp.StatusCode, resp.Header, body),
+	}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API error: %s, status: %d", e.Body, e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed if retried later
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests || e.RateLimited
+}
@@ -0,0 +1,566 @@
+// Package githubtest provides an in-process fake of the parts of the GitHub
+// REST API the bot uses, so pkg/github and the review pipeline can be tested
+// without network access.
+package githubtest
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git-gud-bot/pkg/github"
+)
+
+// Token is the token Client authenticates with; the server accepts no other
+const Token = "githubtest-token"
+
+// maxFiles mirrors the most files GitHub lists for a pull request
+const maxFiles = github.MaxPullRequestFiles
+
+// maxContentSize is the largest file the contents API returns inline; larger
+// files have to be fetched as blobs
+const maxContentSize = 1 << 20
+
+// Write is a request that would have changed something on GitHub
+type Write struct {
+	Method string
+	Path   string
+	Body   []byte
+}
+
+// Server is a fake GitHub API. Register fixtures with AddPullRequest and
+// AddFile, script failures with FailNext, SecondaryRateLimit and SetRateLimit,
+// and inspect what the client posted with Writes and the typed accessors.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	pulls     map[string]github.PullRequest
+	contents  map[string][]byte
+	checkRuns map[int64]*github.CheckRun
+	reviews   map[string][]github.PullRequestReview
+	nextID    int64
+	writes    []Write
+	failures  []failure
+	rate      *github.RateLimit
+}
+
+type failure struct {
+	status int
+	header http.Header
+	body   string
+}
+
+// NewServer starts a fake GitHub server; close it when the test ends
+func NewServer() *Server {
+	s := &Server{
+		pulls:     make(map[string]github.PullRequest),
+		contents:  make(map[string][]byte),
+		checkRuns: make(map[int64]*github.CheckRun),
+		reviews:   make(map[string][]github.PullRequestReview),
+		nextID:    1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Endpoints point a github.Client at the server
+func (s *Server) Endpoints() github.Endpoints {
+	return github.Endpoints{
+		BaseURL:   s.URL,
+		UploadURL: s.URL,
+		WebURL:    s.URL,
+	}
+}
+
+// Client returns a client for the server that authenticates with Token
+func (s *Server) Client() *github.Client {
+	return github.NewClient(Token, github.WithEndpoints(s.Endpoints()))
+}
+
+// AddPullRequest registers a pull request and its files. ChangedFiles
+// defaults to the number of files.
+func (s *Server) AddPullRequest(owner, repo string, pr github.PullRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pr.ChangedFiles == 0 {
+		pr.ChangedFiles = len(pr.Files)
+	}
+	s.pulls[pullKey(owner, repo, pr.Number)] = pr
+}
+
+// AddFile registers a file's contents at ref. An empty ref matches any ref
+// without a more specific fixture.
+func (s *Server) AddFile(owner, repo, ref, path string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.contents[contentKey(owner, repo, ref, path)] = content
+}
+
+// FailNext makes the next n requests fail with status
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{
+			status: status,
+			body:   fmt.Sprintf(`{"message": %q}`, http.StatusText(status)),
+		})
+	}
+}
+
+// SecondaryRateLimit makes the next n requests hit a secondary rate limit
+// asking the client to retry after retryAfter
+func (s *Server) SecondaryRateLimit(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	header := http.Header{}
+	header.Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{
+			status: http.StatusForbidden,
+			header: header,
+			body:   `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`,
+		})
+	}
+}
+
+// SetRateLimit reports a primary rate limit on every response. Each request
+// uses one unit; once remaining reaches zero requests are rejected until
+// reset, like GitHub does.
+func (s *Server) SetRateLimit(limit, remaining int, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rate = &github.RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Used:      limit - remaining,
+		Reset:     reset,
+	}
+}
+
+// Writes returns every POST, PATCH, PUT and DELETE the server received
+func (s *Server) Writes() []Write {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Write(nil), s.writes...)
+}
+
+// Reviews returns the pull request reviews submitted so far
+func (s *Server) Reviews() []github.PullRequestReview {
+	return decodeWrites[github.PullRequestReview](s, "POST", "/reviews")
+}
+
+// ReviewComments returns the single inline comments posted so far
+func (s *Server) ReviewComments() []github.ReviewComment {
+	return decodeWrites[github.ReviewComment](s, "POST", "/pulls/", "/comments")
+}
+
+// IssueComments returns the bodies of the conversation comments posted so far
+func (s *Server) IssueComments() []string {
+	var bodies []string
+	for _, comment := range decodeWrites[github.IssueComment](s, "POST", "/issues/", "/comments") {
+		bodies = append(bodies, comment.Body)
+	}
+	return bodies
+}
+
+// CheckRuns returns every check run in its current state, with annotations
+// from all updates, in creation order
+func (s *Server) CheckRuns() []github.CheckRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]github.CheckRun, 0, len(s.checkRuns))
+	for id := int64(1); id < s.nextID; id++ {
+		if run, ok := s.checkRuns[id]; ok {
+			runs = append(runs, *run)
+		}
+	}
+	return runs
+}
+
+// decodeWrites decodes the bodies of writes with method whose path contains
+// every fragment
+func decodeWrites[T any](s *Server, method string, fragments ...string) []T {
+	var out []T
+	for _, write := range s.Writes() {
+		if write.Method != method || !containsAll(write.Path, fragments) {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(write.Body, &v); err == nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		writeError(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+
+	if len(s.failures) > 0 {
+		f := s.failures[0]
+		s.failures = s.failures[1:]
+		for key, values := range f.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(f.status)
+		io.WriteString(w, f.body)
+		return
+	}
+
+	if s.rate != nil {
+		if s.rate.Remaining == 0 && time.Now().Before(s.rate.Reset) {
+			s.writeRateLimit(w)
+			writeError(w, http.StatusForbidden, "API rate limit exceeded")
+			return
+		}
+		if s.rate.Remaining > 0 {
+			s.rate.Remaining--
+			s.rate.Used++
+		}
+		s.writeRateLimit(w)
+	}
+
+	if r.Method != http.MethodGet {
+		s.writes = append(s.writes, Write{Method: r.Method, Path: r.URL.Path, Body: body})
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 4 || segments[0] != "repos" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	owner, repo, rest := segments[1], segments[2], segments[3:]
+
+	switch {
+	case r.Method == "GET" && rest[0] == "pulls" && len(rest) == 2:
+		s.getPullRequest(w, r, owner, repo, rest[1])
+	case r.Method == "GET" && rest[0] == "pulls" && len(rest) == 3 && rest[2] == "files":
+		s.listFiles(w, r, owner, repo, rest[1])
+	case r.Method == "GET" && rest[0] == "git" && len(rest) == 3 && rest[1] == "blobs":
+		s.getBlob(w, r, owner, repo, rest[2])
+	case r.Method == "GET" && rest[0] == "contents":
+		s.getContents(w, r, owner, repo, strings.Join(rest[1:], "/"))
+	case r.Method == "POST" && rest[0] == "pulls" && len(rest) == 3 && rest[2] == "comments":
+		writeJSON(w, r, http.StatusCreated, json.RawMessage(body))
+	case r.Method == "GET" && rest[0] == "pulls" && len(rest) == 3 && rest[2] == "reviews":
+		s.listReviews(w, r, owner, repo, rest[1])
+	case r.Method == "POST" && rest[0] == "pulls" && len(rest) == 3 && rest[2] == "reviews":
+		s.createReview(w, r, owner, repo, rest[1], body)
+	case r.Method == "GET" && rest[0] == "commits" && len(rest) == 3 && rest[2] == "check-runs":
+		s.listCheckRuns(w, r, rest[1])
+	case r.Method == "POST" && rest[0] == "issues" && len(rest) == 3 && rest[2] == "comments":
+		writeJSON(w, r, http.StatusCreated, json.RawMessage(body))
+	case r.Method == "POST" && rest[0] == "check-runs" && len(rest) == 1:
+		s.createCheckRun(w, r, body)
+	case r.Method == "PATCH" && rest[0] == "check-runs" && len(rest) == 2:
+		s.updateCheckRun(w, r, rest[1], body)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	pr, ok := s.pull(owner, repo, number)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	pr.Files = nil
+	writeJSON(w, r, http.StatusOK, pr)
+}
+
+// listFiles pages through a pull request's files like GitHub, including the
+// Link header and the cap on listed files
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	pr, ok := s.pull(owner, repo, number)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	files := pr.Files
+	if len(files) > maxFiles {
+		files = files[:maxFiles]
+	}
+
+	perPage := queryInt(r.URL.Query(), "per_page", 30)
+	if perPage > 100 {
+		perPage = 100
+	}
+	page := queryInt(r.URL.Query(), "page", 1)
+
+	start := (page - 1) * perPage
+	if start > len(files) {
+		start = len(files)
+	}
+	end := start + perPage
+	if end > len(files) {
+		end = len(files)
+	}
+
+	if end < len(files) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		query.Set("per_page", strconv.Itoa(perPage))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, s.URL, next.RequestURI()))
+	}
+
+	pageFiles := files[start:end]
+	if pageFiles == nil {
+		pageFiles = []github.File{}
+	}
+	writeJSON(w, r, http.StatusOK, pageFiles)
+}
+
+func (s *Server) getContents(w http.ResponseWriter, r *http.Request, owner, repo, path string) {
+	ref := r.URL.Query().Get("ref")
+	content, ok := s.contents[contentKey(owner, repo, ref, path)]
+	if !ok {
+		content, ok = s.contents[contentKey(owner, repo, "", path)]
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	response := map[string]interface{}{
+		"type":     "file",
+		"encoding": "base64",
+		"path":     path,
+		"sha":      blobSHA(content),
+		"size":     len(content),
+		"content":  base64.StdEncoding.EncodeToString(content),
+	}
+	if len(content) > maxContentSize {
+		response["encoding"] = "none"
+		response["content"] = ""
+	}
+
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// getBlob serves any registered file of the repository by its blob SHA
+func (s *Server) getBlob(w http.ResponseWriter, r *http.Request, owner, repo, sha string) {
+	prefix := owner + "/" + repo + "@"
+	for key, content := range s.contents {
+		if strings.HasPrefix(key, prefix) && blobSHA(content) == sha {
+			writeJSON(w, r, http.StatusOK, map[string]interface{}{
+				"sha":      sha,
+				"size":     len(content),
+				"encoding": "base64",
+				"content":  base64.StdEncoding.EncodeToString(content),
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) createReview(w http.ResponseWriter, r *http.Request, owner, repo, number string, body []byte) {
+	var review github.PullRequestReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid request")
+		return
+	}
+
+	review.ID = s.nextID
+	s.nextID++
+	n, _ := strconv.Atoi(number)
+	key := pullKey(owner, repo, n)
+	s.reviews[key] = append(s.reviews[key], review)
+
+	writeJSON(w, r, http.StatusOK, review)
+}
+
+func (s *Server) listReviews(w http.ResponseWriter, r *http.Request, owner, repo, number string) {
+	n, _ := strconv.Atoi(number)
+	reviews := s.reviews[pullKey(owner, repo, n)]
+	if reviews == nil {
+		reviews = []github.PullRequestReview{}
+	}
+	writeJSON(w, r, http.StatusOK, reviews)
+}
+
+// listCheckRuns lists the runs at a commit, newest first, filtered by
+// check_name like GitHub
+func (s *Server) listCheckRuns(w http.ResponseWriter, r *http.Request, sha string) {
+	name := r.URL.Query().Get("check_name")
+
+	runs := []github.CheckRun{}
+	for id := s.nextID - 1; id > 0; id-- {
+		run, ok := s.checkRuns[id]
+		if !ok || run.HeadSHA != sha || (name != "" && run.Name != name) {
+			continue
+		}
+		runs = append(runs, *run)
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"total_count": len(runs),
+		"check_runs":  runs,
+	})
+}
+
+func (s *Server) createCheckRun(w http.ResponseWriter, r *http.Request, body []byte) {
+	var run github.CheckRun
+	if err := json.Unmarshal(body, &run); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid request")
+		return
+	}
+
+	run.ID = s.nextID
+	s.nextID++
+	if run.Output != nil {
+		run.Output.AnnotationsCount = len(run.Output.Annotations)
+	}
+	s.checkRuns[run.ID] = &run
+
+	writeJSON(w, r, http.StatusCreated, run)
+}
+
+func (s *Server) updateCheckRun(w http.ResponseWriter, r *http.Request, id string, body []byte) {
+	runID, _ := strconv.ParseInt(id, 10, 64)
+	run, ok := s.checkRuns[runID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	var update github.CheckRun
+	if err := json.Unmarshal(body, &update); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid request")
+		return
+	}
+
+	if update.Status != "" {
+		run.Status = update.Status
+	}
+	if update.Conclusion != "" {
+		run.Conclusion = update.Conclusion
+	}
+	if update.CompletedAt != nil {
+		run.CompletedAt = update.CompletedAt
+	}
+	if update.Output != nil {
+		// Like GitHub, annotations accumulate across updates
+		var annotations []github.CheckAnnotation
+		if run.Output != nil {
+			annotations = run.Output.Annotations
+		}
+		output := *update.Output
+		output.Annotations = append(annotations, update.Output.Annotations...)
+		output.AnnotationsCount = len(output.Annotations)
+		run.Output = &output
+	}
+
+	writeJSON(w, r, http.StatusOK, run)
+}
+
+func (s *Server) pull(owner, repo, number string) (github.PullRequest, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return github.PullRequest{}, false
+	}
+	pr, ok := s.pulls[pullKey(owner, repo, n)]
+	return pr, ok
+}
+
+func (s *Server) writeRateLimit(w http.ResponseWriter) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.rate.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.rate.Remaining))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(s.rate.Used))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.rate.Reset.Unix(), 10))
+}
+
+// writeJSON writes v with an ETag and answers If-None-Match with 304, so
+// conditional requests can be tested
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		sum := sha1.Sum(data)
+		etag := `"` + hex.EncodeToString(sum[:]) + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"message": %q}`, message)
+}
+
+func queryInt(query url.Values, key string, fallback int) int {
+	if n, err := strconv.Atoi(query.Get(key)); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+func containsAll(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if !strings.Contains(s, fragment) {
+			return false
+		}
+	}
+	return true
+}
+
+// blobSHA hashes content the way Git names blobs
+func blobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func pullKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+func contentKey(owner, repo, ref, path string) string {
+	return fmt.Sprintf("%s/%s@%s:%s", owner, repo, ref, path)
+}
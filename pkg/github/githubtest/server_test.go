@@ -0,0 +1,112 @@
+package githubtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"git-gud-bot/pkg/github"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerPullRequests(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	files := make([]github.File, 250)
+	for i := range files {
+		files[i] = github.File{Name: fmt.Sprintf("file%d.go", i), Status: "modified"}
+	}
+	server.AddPullRequest("octo", "app", github.PullRequest{Number: 7, Title: "Add things", Files: files})
+	server.AddFile("octo", "app", "", "main.go", []byte("package main\n"))
+
+	client := server.Client()
+	ctx := context.Background()
+
+	pr, err := client.GetPullRequest(ctx, "octo", "app", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Add things", pr.Title)
+	assert.Len(t, pr.Files, 250)
+	assert.False(t, pr.FilesTruncated)
+
+	content, err := client.GetFileContents(ctx, "octo", "app", "main.go", "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+
+	_, err = client.GetFileContents(ctx, "octo", "app", "missing.go", "abc123")
+	var apiErr *github.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	}
+}
+
+func TestServerRecordsWrites(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	ctx := context.Background()
+
+	require.NoError(t, client.CreateIssueComment(ctx, "octo", "app", 7, "hello"))
+	require.NoError(t, client.CreatePullRequestReview(ctx, "octo", "app", 7, &github.PullRequestReview{
+		Body:     "summary",
+		Event:    github.ReviewEventComment,
+		Comments: []github.DraftReviewComment{{Path: "main.go", Position: 3, Body: "nit"}},
+	}))
+
+	run, err := client.CreateCheckRun(ctx, "octo", "app", &github.CheckRun{Name: "bot", Status: github.CheckStatusInProgress})
+	require.NoError(t, err)
+	for _, conclusion := range []string{"", github.CheckConclusionSuccess} {
+		update := &github.CheckRun{Conclusion: conclusion, Output: &github.CheckRunOutput{
+			Title:       "done",
+			Annotations: []github.CheckAnnotation{{Path: "main.go", StartLine: 1, EndLine: 1}},
+		}}
+		if conclusion != "" {
+			update.Status = github.CheckStatusCompleted
+		}
+		_, err := client.UpdateCheckRun(ctx, "octo", "app", run.ID, update)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"hello"}, server.IssueComments())
+	if reviews := server.Reviews(); assert.Len(t, reviews, 1) {
+		assert.Len(t, reviews[0].Comments, 1)
+	}
+	runs := server.CheckRuns()
+	require.Len(t, runs, 1)
+	assert.Equal(t, github.CheckStatusCompleted, runs[0].Status)
+	assert.Equal(t, github.CheckConclusionSuccess, runs[0].Conclusion)
+	assert.Len(t, runs[0].Output.Annotations, 2, "annotations accumulate across updates")
+	assert.Len(t, server.Writes(), 5)
+}
+
+func TestServerScriptedFailures(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddPullRequest("octo", "app", github.PullRequest{Number: 1})
+
+	client := server.Client()
+	ctx := context.Background()
+
+	// The client retries both and succeeds
+	server.FailNext(1, http.StatusBadGateway)
+	server.SecondaryRateLimit(1, 0)
+	_, err := client.GetPullRequest(ctx, "octo", "app", 1)
+	require.NoError(t, err, "retries succeed")
+
+	server.SetRateLimit(5000, 1, time.Now().Add(time.Hour))
+	_, err = client.GetPullRequestFiles(ctx, "octo", "app", 1)
+	require.NoError(t, err)
+	limit, ok, err := client.RateLimit(ctx, "octo", "app")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 0, limit.Remaining)
+
+	var rateErr *github.RateLimitError
+	_, err = client.GetPullRequestFiles(ctx, "octo", "app", 1)
+	assert.ErrorAs(t, err, &rateErr, "the limit is exhausted")
+}
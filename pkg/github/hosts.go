@@ -0,0 +1,104 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultHost is the hostname of github.com
+const DefaultHost = "github.com"
+
+// ErrUnknownHost is returned for a GitHub host no client was configured for
+var ErrUnknownHost = errors.New("unknown GitHub host")
+
+// Endpoints locate a GitHub instance: the REST API, the upload API and the
+// web interface
+type Endpoints struct {
+	BaseURL   string
+	UploadURL string
+	WebURL    string
+}
+
+// DotComEndpoints are the endpoints of github.com
+func DotComEndpoints() Endpoints {
+	return Endpoints{
+		BaseURL:   "https://api.github.com",
+		UploadURL: "https://uploads.github.com",
+		WebURL:    "https://github.com",
+	}
+}
+
+// EnterpriseEndpoints derives the endpoints of a GitHub Enterprise Server
+// instance from its web URL, filling in apiURL and uploadURL when they are
+// empty
+func EnterpriseEndpoints(webURL, apiURL, uploadURL string) (Endpoints, error) {
+	u, err := url.Parse(strings.TrimSuffix(webURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return Endpoints{}, fmt.Errorf("invalid GitHub web URL %q", webURL)
+	}
+
+	endpoints := Endpoints{
+		BaseURL:   strings.TrimSuffix(apiURL, "/"),
+		UploadURL: strings.TrimSuffix(uploadURL, "/"),
+		WebURL:    u.String(),
+	}
+	if endpoints.BaseURL == "" {
+		endpoints.BaseURL = endpoints.WebURL + "/api/v3"
+	}
+	if endpoints.UploadURL == "" {
+		endpoints.UploadURL = endpoints.WebURL + "/api/uploads"
+	}
+
+	return endpoints, nil
+}
+
+// Host returns the hostname of the web URL, which is what webhooks and
+// review requests identify an instance by
+func (e Endpoints) Host() string {
+	u, err := url.Parse(e.WebURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// Hosts routes requests to the client configured for each GitHub host
+type Hosts struct {
+	clients map[string]*Client
+}
+
+func NewHosts() *Hosts {
+	return &Hosts{
+		clients: make(map[string]*Client),
+	}
+}
+
+// Add registers a client under its web hostname and, when different, under
+// the configured name
+func (h *Hosts) Add(name string, client *Client) {
+	h.clients[NormalizeHost(client.Endpoints().Host())] = client
+	if name != "" {
+		h.clients[NormalizeHost(name)] = client
+	}
+}
+
+// Client returns the client for a hostname or configured name. An empty host
+// means github.com.
+func (h *Hosts) Client(host string) (*Client, error) {
+	client, ok := h.clients[NormalizeHost(host)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownHost, host)
+	}
+	return client, nil
+}
+
+// NormalizeHost lowercases a host and maps "" to DefaultHost
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return DefaultHost
+	}
+	return host
+}
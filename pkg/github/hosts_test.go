@@ -0,0 +1,56 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnterpriseEndpoints(t *testing.T) {
+	endpoints, err := EnterpriseEndpoints("https://ghe.example.com/", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, Endpoints{
+		BaseURL:   "https://ghe.example.com/api/v3",
+		UploadURL: "https://ghe.example.com/api/uploads",
+		WebURL:    "https://ghe.example.com",
+	}, endpoints)
+
+	endpoints, err = EnterpriseEndpoints("https://ghe.example.com", "https://api.ghe.example.com/", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.ghe.example.com", endpoints.BaseURL, "explicit API URL not used")
+
+	_, err = EnterpriseEndpoints("ghe.example.com", "", "")
+	assert.Error(t, err, "a URL without a scheme is rejected")
+}
+
+func TestHosts(t *testing.T) {
+	dotCom := NewClient("a")
+	endpoints, _ := EnterpriseEndpoints("https://GHE.example.com", "", "")
+	enterprise := NewClient("b", WithEndpoints(endpoints))
+
+	hosts := NewHosts()
+	hosts.Add("github.com", dotCom)
+	hosts.Add("ghe", enterprise)
+
+	tests := []struct {
+		host string
+		want *Client
+	}{
+		{"", dotCom},
+		{"github.com", dotCom},
+		{"ghe.example.com", enterprise},
+		{"GHE.Example.com", enterprise},
+		{"ghe", enterprise},
+	}
+
+	for _, tt := range tests {
+		got, err := hosts.Client(tt.host)
+		if assert.NoError(t, err, tt.host) {
+			assert.Same(t, tt.want, got, tt.host)
+		}
+	}
+
+	_, err := hosts.Client("gitlab.com")
+	assert.ErrorIs(t, err, ErrUnknownHost)
+}
@@ -0,0 +1,76 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// perPage is the largest page size GitHub's list endpoints accept
+const perPage = 100
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// listAll follows the Link headers of a list endpoint and collects up to limit
+// items; limit <= 0 collects every page
+func listAll[T any](ctx context.Context, c *Client, owner, repo, endpoint string, limit int) ([]T, error) {
+	next, err := withPerPage(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	for next != "" && (limit <= 0 || len(items) < limit) {
+		var page []T
+		next, err = c.getPage(ctx, owner, repo, next, &page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+	}
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// getPage decodes one page into out and returns the URL of the next page, or
+// "" on the last one
+func (c *Client) getPage(ctx context.Context, owner, repo, pageURL string, out interface{}) (string, error) {
+	resp, err := c.send(ctx, owner, repo, "GET", pageURL, nil, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nextPage(resp.Header.Get("Link")), nil
+}
+
+func nextPage(link string) string {
+	if match := nextLinkPattern.FindStringSubmatch(link); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func withPerPage(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", endpoint, err)
+	}
+
+	query := u.Query()
+	query.Set("per_page", fmt.Sprint(perPage))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
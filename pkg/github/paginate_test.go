@@ -0,0 +1,72 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPullRequestPaginatesFiles(t *testing.T) {
+	const changed = 3100
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/pulls/1":
+			fmt.Fprintf(w, `{"number": 1, "changed_files": %d}`, changed)
+		case "/repos/o/r/pulls/1/files":
+			assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 0 {
+				page = 1
+			}
+
+			// GitHub stops listing after MaxPullRequestFiles
+			last := MaxPullRequestFiles / perPage
+			if page < last {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=100&page=%d>; rel="next", <%s%s?per_page=100&page=%d>; rel="last"`,
+					server.URL, r.URL.Path, page+1, server.URL, r.URL.Path, last))
+			}
+
+			files := make([]File, perPage)
+			for i := range files {
+				files[i].Name = fmt.Sprintf("file%d.go", (page-1)*perPage+i)
+			}
+			json.NewEncoder(w).Encode(files)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	pr, err := client.GetPullRequest(context.Background(), "o", "r", 1)
+	require.NoError(t, err)
+
+	require.Len(t, pr.Files, MaxPullRequestFiles)
+	assert.Equal(t, "file2999.go", pr.Files[len(pr.Files)-1].Name)
+	assert.True(t, pr.FilesTruncated, "a PR with more files than GitHub lists is truncated")
+}
+
+func TestNextPage(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, nextPage(tt.link), tt.link)
+	}
+}
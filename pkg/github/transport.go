@@ -0,0 +1,467 @@
+package github
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTimeout bounds a whole call, including retries and rate-limit pauses
+	defaultTimeout = 2 * time.Minute
+
+	defaultMaxRetries   = 3
+	defaultBaseBackoff  = time.Second
+	defaultMaxBackoff   = 30 * time.Second
+	defaultMaxRateWait  = time.Minute
+	responseHeaderLimit = 30 * time.Second
+
+	// defaultMaxCacheBytes bounds the bodies kept for revalidation; a single
+	// response larger than a sixteenth of it is not cached at all
+	defaultMaxCacheBytes = 32 << 20
+	// maxRateLimits bounds the identities whose rate-limit state is kept
+	maxRateLimits = 4096
+)
+
+// RateLimit is the primary rate-limit state GitHub last reported for a token
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+}
+
+// RateLimitError is returned instead of waiting when the primary rate limit
+// is exhausted and resets later than the transport is willing to pause
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub rate limit exhausted until %s", e.Reset.Format(time.RFC3339))
+}
+
+// Temporary reports that the request will succeed once the limit resets
+func (e *RateLimitError) Temporary() bool {
+	return true
+}
+
+// Transport is an http.RoundTripper that keeps a client within GitHub's rate
+// limits. It retries server errors and secondary rate limits with jittered
+// backoff, pauses while the primary limit is exhausted and revalidates cached
+// GET responses with If-None-Match, which GitHub does not count against the
+// limit when the resource is unchanged.
+//
+// Server errors are only retried for idempotent methods; a POST that failed
+// with a 502 may still have been applied.
+//
+// Rate limits and cached responses are kept per identity: the app
+// installation a Client's requests are made as, or a hash of the token for
+// other requests. Installation tokens rotate hourly, but their installation's
+// limit and the responses it may see do not.
+type Transport struct {
+	Base        http.RoundTripper
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// MaxRateWait is the longest a request waits for the primary limit to
+	// reset before failing with a *RateLimitError
+	MaxRateWait time.Duration
+	// MaxCacheBytes bounds the response bodies kept for revalidation; the
+	// least recently used are evicted first
+	MaxCacheBytes int
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu         sync.Mutex
+	limits     map[string]RateLimit
+	cache      map[string]*list.Element
+	cacheOrder *list.List
+	cacheBytes int
+}
+
+type cachedResponse struct {
+	key    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func NewTransport() *Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = responseHeaderLimit
+
+	return &Transport{
+		Base:          base,
+		MaxRetries:    defaultMaxRetries,
+		BaseBackoff:   defaultBaseBackoff,
+		MaxBackoff:    defaultMaxBackoff,
+		MaxRateWait:   defaultMaxRateWait,
+		MaxCacheBytes: defaultMaxCacheBytes,
+		now:           time.Now,
+		sleep:         sleepContext,
+		limits:        make(map[string]RateLimit),
+		cache:         make(map[string]*list.Element),
+		cacheOrder:    list.New(),
+	}
+}
+
+// RateLimit returns the last reported state for requests made as the given
+// identity
+func (t *Transport) RateLimit(identity string) (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limit, ok := t.limits[identity]
+	return limit, ok
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	identity := requestIdentity(ctx, req.Header.Get("Authorization"))
+
+	if err := t.waitForReset(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	cacheKey := ""
+	var cached *cachedResponse
+	if req.Method == http.MethodGet {
+		cacheKey = identity + " " + req.URL.String()
+		cached = t.cached(cacheKey)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := t.prepare(req, attempt, cached)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if err != nil {
+			if attempt >= t.MaxRetries || !idempotent(req.Method) || !retryableError(err) {
+				return nil, err
+			}
+			if err := t.sleep(ctx, t.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		t.recordRateLimit(identity, resp.Header)
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			return cached.response(req), nil
+		}
+
+		if resp.StatusCode == http.StatusForbidden {
+			if err := bufferBody(resp); err != nil {
+				return nil, err
+			}
+		}
+
+		delay, retry := t.retryDelay(req, resp, attempt)
+		if !retry {
+			if cacheKey != "" {
+				return t.store(cacheKey, resp)
+			}
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// prepare clones the request for an attempt, rewinding its body on retries and
+// adding the validator of a cached response
+func (t *Transport) prepare(req *http.Request, attempt int, cached *cachedResponse) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if attempt > 0 && req.Body != nil {
+		if req.GetBody == nil {
+			return nil, errors.New("cannot retry request with a non-rewindable body")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	if cached != nil {
+		clone.Header.Set("If-None-Match", cached.etag)
+	}
+	return clone, nil
+}
+
+// retryDelay decides whether a response is worth retrying and how long to wait
+func (t *Transport) retryDelay(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	if attempt >= t.MaxRetries {
+		return 0, false
+	}
+
+	var body []byte
+	if buffered, ok := resp.Body.(*bufferedBody); ok {
+		body = buffered.data
+	}
+
+	switch {
+	case isRateLimited(resp.StatusCode, resp.Header, body):
+		if after, ok := retryAfter(resp.Header); ok {
+			return after, after <= t.MaxRateWait
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			wait := t.untilReset(resp.Header)
+			return wait, wait <= t.MaxRateWait
+		}
+		// Secondary limits without guidance: GitHub asks for at least a minute
+		return t.MaxRateWait, true
+	case resp.StatusCode >= http.StatusInternalServerError && idempotent(req.Method):
+		return t.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// waitForReset pauses while the primary limit for this identity is exhausted
+func (t *Transport) waitForReset(ctx context.Context, identity string) error {
+	limit, ok := t.RateLimit(identity)
+	if !ok || limit.Remaining > 0 {
+		return nil
+	}
+
+	wait := limit.Reset.Sub(t.now())
+	if wait <= 0 {
+		return nil
+	}
+	if wait > t.MaxRateWait {
+		return &RateLimitError{Reset: limit.Reset}
+	}
+	return t.sleep(ctx, wait)
+}
+
+func (t *Transport) recordRateLimit(identity string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.limits[identity]; !ok && len(t.limits) >= maxRateLimits {
+		t.pruneLimits()
+	}
+	t.limits[identity] = RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Used:      used,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+// pruneLimits drops limits that have reset, and an arbitrary one if none
+// has; an identity without state is simply not paused. Callers hold t.mu.
+func (t *Transport) pruneLimits() {
+	now := t.now()
+	for identity, limit := range t.limits {
+		if !limit.Reset.After(now) {
+			delete(t.limits, identity)
+		}
+	}
+	if len(t.limits) < maxRateLimits {
+		return
+	}
+	for identity := range t.limits {
+		delete(t.limits, identity)
+		return
+	}
+}
+
+func (t *Transport) untilReset(header http.Header) time.Duration {
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return t.MaxRateWait
+	}
+	if wait := time.Unix(reset, 0).Sub(t.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// backoff returns an exponential delay with jitter for the given attempt
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.MaxBackoff
+	if attempt < 32 {
+		delay = t.BaseBackoff << attempt
+	}
+	if delay <= 0 || delay > t.MaxBackoff {
+		delay = t.MaxBackoff
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (t *Transport) cached(key string) *cachedResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.cache[key]
+	if !ok {
+		return nil
+	}
+	t.cacheOrder.MoveToFront(elem)
+	return elem.Value.(*cachedResponse)
+}
+
+// store buffers a successful GET response so it can be revalidated later
+func (t *Transport) store(key string, resp *http.Response) (*http.Response, error) {
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.evict(key)
+	if len(body) > t.MaxCacheBytes/16 {
+		return resp, nil
+	}
+
+	// Revalidation is an optimization only, so the least recently used
+	// responses make room
+	for t.cacheBytes+len(body) > t.MaxCacheBytes && t.cacheOrder.Len() > 0 {
+		t.evict(t.cacheOrder.Back().Value.(*cachedResponse).key)
+	}
+	entry := &cachedResponse{key: key, etag: etag, header: resp.Header.Clone(), body: body}
+	t.cache[key] = t.cacheOrder.PushFront(entry)
+	t.cacheBytes += len(body)
+
+	return resp, nil
+}
+
+// evict drops a cached response. Callers hold t.mu.
+func (t *Transport) evict(key string) {
+	elem, ok := t.cache[key]
+	if !ok {
+		return
+	}
+	t.cacheOrder.Remove(elem)
+	delete(t.cache, key)
+	t.cacheBytes -= len(elem.Value.(*cachedResponse).body)
+}
+
+// bufferedBody is a response body read into memory so it can be inspected
+// before the caller sees it
+type bufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (b *bufferedBody) Close() error {
+	return nil
+}
+
+func bufferBody(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = &bufferedBody{Reader: bytes.NewReader(data), data: data}
+	return nil
+}
+
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// isRateLimited recognizes primary and secondary rate-limit responses. GitHub
+// reports both as 403 or 429; body is checked when it has already been read.
+func isRateLimited(status int, header http.Header, body []byte) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	if status != http.StatusForbidden {
+		return false
+	}
+	if header.Get("Retry-After") != "" || header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+	return bytes.Contains(bytes.ToLower(body), []byte("rate limit"))
+}
+
+func retryAfter(header http.Header) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+func idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryableError reports whether a transport error is likely transient
+func retryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || strings.Contains(err.Error(), "connection reset")
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
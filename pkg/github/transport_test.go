@@ -0,0 +1,266 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTransport() (*Transport, *[]time.Duration) {
+	var slept []time.Duration
+	transport := NewTransport()
+	transport.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return transport, &slept
+}
+
+func TestTransportRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch {
+		case r.URL.Path == "/flaky" && calls < 3:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/secondary" && calls == 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit"}`)
+		case r.URL.Path == "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+		case r.URL.Path == "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantCalls int
+	}{
+		{"server errors are retried", "GET", "/flaky", http.StatusOK, 3},
+		{"secondary limit honors Retry-After", "POST", "/secondary", http.StatusOK, 2},
+		{"permission errors are final", "GET", "/forbidden", http.StatusForbidden, 1},
+		{"POST is not retried on server errors", "POST", "/broken", http.StatusInternalServerError, 1},
+		{"retries are bounded", "GET", "/broken", http.StatusInternalServerError, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			transport, _ := newTestTransport()
+			client := &http.Client{Transport: transport}
+
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader("payload"))
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Equal(t, tt.wantCalls, calls)
+			if resp.StatusCode == http.StatusOK && tt.method == "POST" {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, "payload", string(body), "retried body")
+			}
+		})
+	}
+}
+
+// newETagServer serves a fixed body with an ETag and counts the full
+// responses it sends
+func newETagServer(t *testing.T, body string) (*httptest.Server, *int) {
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches++
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func get(t *testing.T, client *http.Client, ctx context.Context, url, token string) string {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestTransportConditionalRequests(t *testing.T) {
+	server, fetches := newETagServer(t, `{"number": 1}`)
+	transport, _ := newTestTransport()
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, `{"number": 1}`, get(t, client, context.Background(), server.URL+"/repos/o/r/pulls/1", ""))
+	}
+	assert.Equal(t, 1, *fetches, "full responses sent")
+}
+
+func TestTransportCacheFollowsIdentity(t *testing.T) {
+	server, fetches := newETagServer(t, `{"number": 1}`)
+	transport, _ := newTestTransport()
+	client := &http.Client{Transport: transport}
+	url := server.URL + "/repos/o/r/pulls/1"
+
+	// A rotated installation token still revalidates the cached response
+	installation := withIdentity(context.Background(), "app/1/installation/7")
+	get(t, client, installation, url, "ghs_first")
+	get(t, client, installation, url, "ghs_second")
+	assert.Equal(t, 1, *fetches)
+
+	// Another token may see different content, so it starts afresh
+	get(t, client, context.Background(), url, "ghp_other")
+	assert.Equal(t, 2, *fetches)
+}
+
+func TestTransportCacheIsBoundedByBytes(t *testing.T) {
+	server, fetches := newETagServer(t, strings.Repeat("x", 100))
+	transport, _ := newTestTransport()
+	transport.MaxCacheBytes = 16 * 100
+	client := &http.Client{Transport: transport}
+	ctx := context.Background()
+	url := func(i int) string { return fmt.Sprintf("%s/%d", server.URL, i) }
+
+	for i := 0; i < 16; i++ {
+		get(t, client, ctx, url(i), "")
+	}
+	get(t, client, ctx, url(0), "")
+	assert.Equal(t, 16, *fetches, "the cache holds sixteen responses")
+
+	// 1 was least recently used when 16 needed room
+	get(t, client, ctx, url(16), "")
+	assert.LessOrEqual(t, transport.cacheBytes, transport.MaxCacheBytes)
+	get(t, client, ctx, url(0), "")
+	assert.Equal(t, 17, *fetches)
+	get(t, client, ctx, url(1), "")
+	assert.Equal(t, 18, *fetches)
+
+	// Responses too large for the cache are passed through
+	transport.MaxCacheBytes = 16 * 50
+	get(t, client, ctx, url(100), "")
+	get(t, client, ctx, url(100), "")
+	assert.Equal(t, 20, *fetches)
+}
+
+func TestTransportPrimaryRateLimit(t *testing.T) {
+	now := time.Now()
+	reset := now.Add(time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Used", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	transport, slept := newTestTransport()
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	limit, ok := transport.RateLimit("")
+	require.True(t, ok)
+	assert.Equal(t, 0, limit.Remaining)
+	assert.Equal(t, 5000, limit.Limit)
+	assert.Equal(t, reset.Unix(), limit.Reset.Unix())
+
+	// The reset is beyond MaxRateWait, so the next call fails fast
+	_, err = client.Get(server.URL)
+	var rateErr *RateLimitError
+	assert.ErrorAs(t, err, &rateErr)
+
+	// A reset within MaxRateWait is waited out
+	reset = now.Add(20 * time.Second)
+	transport.recordRateLimit("", http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+	})
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	if assert.Len(t, *slept, 1, "one pause until reset") {
+		assert.LessOrEqual(t, (*slept)[0], 20*time.Second)
+	}
+}
+
+func TestTransportRateLimitFollowsIdentity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	transport, _ := newTestTransport()
+	client := &http.Client{Transport: transport}
+
+	get(t, client, withIdentity(context.Background(), "app/1/installation/7"), server.URL, "ghs_first")
+	limit, ok := transport.RateLimit("app/1/installation/7")
+	require.True(t, ok)
+	assert.Equal(t, 42, limit.Remaining)
+
+	// Without an identity the token is known only by its hash
+	get(t, client, context.Background(), server.URL, "ghp_secret")
+	_, ok = transport.RateLimit(tokenIdentity("Bearer ghp_secret"))
+	assert.True(t, ok)
+	for identity := range transport.limits {
+		assert.NotContains(t, identity, "ghp_secret")
+	}
+}
+
+func TestTransportLimitsAreBounded(t *testing.T) {
+	now := time.Now()
+	transport, _ := newTestTransport()
+	transport.now = func() time.Time { return now }
+
+	record := func(identity string, reset time.Time) {
+		transport.recordRateLimit(identity, http.Header{
+			"X-Ratelimit-Remaining": {"1"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+		})
+	}
+	for i := 0; i < maxRateLimits; i++ {
+		record(strconv.Itoa(i), now.Add(-time.Minute))
+	}
+	record("current", now.Add(time.Hour))
+
+	assert.Len(t, transport.limits, 1, "limits that have reset are dropped")
+	_, ok := transport.RateLimit("current")
+	assert.True(t, ok)
+}
@@ -0,0 +1,131 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Webhook event names sent in the X-GitHub-Event header
+const (
+	EventPing        = "ping"
+	EventPullRequest = "pull_request"
+)
+
+// Pull request event actions that should trigger a review
+const (
+	ActionOpened      = "opened"
+	ActionSynchronize = "synchronize"
+	ActionReopened    = "reopened"
+)
+
+const signaturePrefix = "sha256="
+
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrMissingSecret    = errors.New("webhook secret is not configured")
+)
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type Repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    User   `json:"owner"`
+	HTMLURL  string `json:"html_url,omitempty"`
+}
+
+// Host returns the hostname of the GitHub instance the repository lives on,
+// or "" when its web URL is missing
+func (r Repository) Host() string {
+	u, err := url.Parse(r.HTMLURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+// ValidateSignature checks the X-Hub-Signature-256 header value against the
+// HMAC-SHA256 of the raw payload using the shared webhook secret.
+func ValidateSignature(payload []byte, signature string, secret []byte) error {
+	if len(secret) == 0 {
+		return ErrMissingSecret
+	}
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// PayloadHost returns the GitHub host a delivery's payload belongs to, taken
+// from its repository's web URL. Unlike the X-GitHub-Enterprise-Host header,
+// the payload is covered by the signature. It is "" for events that name no
+// repository, such as ping.
+func PayloadHost(payload []byte) (string, error) {
+	var event struct {
+		Repository *Repository `json:"repository"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to decode event: %w", err)
+	}
+	if event.Repository == nil {
+		return "", nil
+	}
+	return event.Repository.Host(), nil
+}
+
+func ParsePullRequestEvent(payload []byte) (*PullRequestEvent, error) {
+	var event PullRequestEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode pull_request event: %w", err)
+	}
+
+	if event.Repository.Owner.Login == "" || event.Repository.Name == "" || event.Number == 0 {
+		return nil, fmt.Errorf("pull_request event is missing repository or number")
+	}
+	if event.Repository.Host() == "" {
+		return nil, fmt.Errorf("pull_request event is missing the repository URL")
+	}
+
+	return &event, nil
+}
+
+// ShouldReview reports whether the event action represents new code to review
+func (e *PullRequestEvent) ShouldReview() bool {
+	switch e.Action {
+	case ActionOpened, ActionSynchronize, ActionReopened:
+		return true
+	default:
+		return false
+	}
+}
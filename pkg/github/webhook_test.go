@@ -0,0 +1,67 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(payload, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"action":"opened"}`)
+	secret := []byte("s3cret")
+
+	assert.NoError(t, ValidateSignature(payload, sign(payload, secret), secret))
+	assert.ErrorIs(t, ValidateSignature(payload, sign(payload, []byte("other")), secret), ErrInvalidSignature)
+	assert.ErrorIs(t, ValidateSignature(payload, "sha1=abc", secret), ErrInvalidSignature)
+	assert.ErrorIs(t, ValidateSignature(payload, "sha256=zz", secret), ErrInvalidSignature)
+	assert.ErrorIs(t, ValidateSignature(payload, "", secret), ErrMissingSignature)
+	assert.ErrorIs(t, ValidateSignature(payload, sign(payload, nil), nil), ErrMissingSecret)
+}
+
+func TestParsePullRequestEvent(t *testing.T) {
+	payload := []byte(`{
+		"action": "synchronize",
+		"number": 42,
+		"pull_request": {"title": "Add feature", "head": {"sha": "abc123"}},
+		"repository": {"name": "git-gud-bot", "owner": {"login": "octocat"}, "html_url": "https://github.com/octocat/git-gud-bot"}
+	}`)
+
+	event, err := ParsePullRequestEvent(payload)
+	require.NoError(t, err)
+	assert.Equal(t, 42, event.Number)
+	assert.Equal(t, "octocat", event.Repository.Owner.Login)
+	assert.Equal(t, "abc123", event.PullRequest.Head.SHA)
+	assert.True(t, event.ShouldReview())
+
+	event.Action = "closed"
+	assert.False(t, event.ShouldReview())
+
+	_, err = ParsePullRequestEvent([]byte(`{"action": "opened"}`))
+	assert.Error(t, err)
+
+	_, err = ParsePullRequestEvent([]byte(`{"number": 1, "repository": {"name": "r", "owner": {"login": "o"}}}`))
+	assert.Error(t, err, "the repository URL locates the host")
+}
+
+func TestPayloadHost(t *testing.T) {
+	host, err := PayloadHost([]byte(`{"repository": {"html_url": "https://GHE.example.com/octo/app"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "ghe.example.com", host)
+
+	host, err = PayloadHost([]byte(`{"zen": "Keep it logically awesome."}`))
+	require.NoError(t, err)
+	assert.Empty(t, host, "events without a repository name no host")
+
+	_, err = PayloadHost([]byte(`not json`))
+	assert.Error(t, err)
+}